@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"order-app/config"
+	"order-app/internal/domain"
+	"order-app/internal/export"
+	"order-app/internal/fieldcrypt"
+	"order-app/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+func runExport(cfg *config.Config, zapLogger *zap.Logger, args []string) (err error) {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	formatFlag := fs.String("format", string(export.FormatNDJSON), "формат выгрузки: ndjson или csv")
+	out := fs.String("out", "-", "файл для выгрузки, - означает stdout")
+	gz := fs.Bool("gzip", false, "сжимать выгрузку gzip")
+	deliveryService := fs.String("delivery-service", "", "выгрузить только заказы этой службы доставки")
+	customerID := fs.String("customer-id", "", "выгрузить только заказы этого покупателя")
+	from := fs.String("from", "", "date_created не раньше (RFC 3339)")
+	to := fs.String("to", "", "date_created раньше (RFC 3339)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	format, err := export.ParseFormat(*formatFlag)
+	if err != nil {
+		return err
+	}
+	filter := repository.ExportFilter{DeliveryService: *deliveryService, CustomerID: *customerID}
+	if filter.From, err = timeArg("--from", *from); err != nil {
+		return err
+	}
+	if filter.To, err = timeArg("--to", *to); err != nil {
+		return err
+	}
+
+	keyring, err := fieldcrypt.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	pool, err := config.InitDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	shardPools, err := config.InitShardDBs(cfg)
+	if err != nil {
+		return err
+	}
+	shardRepos := []*repository.OrderRepository{repository.NewOrderRepository(pool).WithEncryption(keyring)}
+	for _, p := range shardPools {
+		defer p.Close()
+		shardRepos = append(shardRepos, repository.NewOrderRepository(p).WithEncryption(keyring))
+	}
+	repo := repository.NewShardedOrderRepository(shardRepos...)
+
+	var dst io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("не удалось создать файл выгрузки: %w", err)
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil && cerr != nil {
+				err = fmt.Errorf("не удалось записать файл выгрузки: %w", cerr)
+			}
+		}()
+		dst = f
+	}
+
+	buf := bufio.NewWriter(dst)
+	var gzw *gzip.Writer
+	if *gz {
+		gzw = gzip.NewWriter(buf)
+		dst = gzw
+	} else {
+		dst = buf
+	}
+
+	w, err := export.NewWriter(format, dst)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	count := 0
+	err = repo.ExportOrders(context.Background(), filter, func(o *domain.Order) error {
+		count++
+		return w.Write(o)
+	})
+	if err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if gzw != nil {
+		if err := gzw.Close(); err != nil {
+			return err
+		}
+	}
+	if err := buf.Flush(); err != nil {
+		return fmt.Errorf("не удалось записать выгрузку: %w", err)
+	}
+
+	zapLogger.Info("Выгрузка заказов завершена",
+		zap.String("format", string(format)),
+		zap.String("out", *out),
+		zap.Bool("gzip", *gz),
+		zap.Int("orders", count),
+		zap.Duration("duration", time.Since(start)),
+	)
+	return nil
+}
+
+func timeArg(name, val string) (time.Time, error) {
+	if val == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: ожидалось время в формате RFC 3339, получено %q", name, val)
+	}
+	return t, nil
+}
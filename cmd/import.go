@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"order-app/config"
+	"order-app/internal/cache"
+	"order-app/internal/domain"
+	"order-app/internal/fieldcrypt"
+	"order-app/internal/importer"
+	"order-app/internal/repository"
+	"order-app/internal/service"
+
+	"go.uber.org/zap"
+)
+
+func runImport(cfg *config.Config, zapLogger *zap.Logger, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "только проверить записи, ничего не сохраняя")
+	concurrency := fs.Int("concurrency", 4, "количество параллельно обрабатываемых записей")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("не указаны файлы для импорта\n\n%s", usage)
+	}
+	if *concurrency <= 0 {
+		return fmt.Errorf("--concurrency должен быть больше нуля, получено %d", *concurrency)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	store := func(context.Context, *domain.Order) (bool, error) {
+		return false, errors.New("сохранение недоступно в режиме --dry-run")
+	}
+	if !*dryRun {
+		svc, closeDB, err := newShardedOrderService(cfg, zapLogger)
+		if err != nil {
+			return err
+		}
+		defer closeDB()
+		store = svc.ProcessOrder
+	}
+
+	im, err := importer.New(store, importer.Options{DryRun: *dryRun, Concurrency: *concurrency})
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	report, err := im.Import(ctx, fs.Args())
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if encErr := enc.Encode(report); encErr != nil {
+		zapLogger.Error("Не удалось вывести отчёт импорта", zap.Error(encErr))
+	}
+	zapLogger.Info("Импорт заказов завершён",
+		zap.Int("files", report.Files),
+		zap.Int("read", report.Read),
+		zap.Int("accepted", report.Accepted),
+		zap.Int("duplicates", report.Duplicates),
+		zap.Int("rejected", report.Rejected),
+		zap.Int("failed", report.Failed),
+		zap.Bool("dry_run", report.DryRun),
+		zap.Duration("duration", time.Since(start)),
+	)
+
+	if err != nil {
+		return err
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf("не удалось сохранить %d заказов", report.Failed)
+	}
+	return nil
+}
+
+// newShardedOrderService собирает OrderService над primary всех шардов, без реплик
+// и Kafka, для пакетных команд import и kafka replay. closeDB закрывает пулы.
+func newShardedOrderService(cfg *config.Config, zapLogger *zap.Logger) (*service.OrderService, func(), error) {
+	keyring, err := fieldcrypt.FromConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pool, err := config.InitDB(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	shardPools, err := config.InitShardDBs(cfg)
+	if err != nil {
+		pool.Close()
+		return nil, nil, err
+	}
+
+	shardRepos := []*repository.OrderRepository{repository.NewOrderRepository(pool).WithEncryption(keyring)}
+	for _, p := range shardPools {
+		shardRepos = append(shardRepos, repository.NewOrderRepository(p).WithEncryption(keyring))
+	}
+	repo := repository.NewShardedOrderRepository(shardRepos...)
+
+	closeDB := func() {
+		pool.Close()
+		for _, p := range shardPools {
+			p.Close()
+		}
+	}
+	return service.NewOrderService(repo, cache.NewCache(time.Minute), zapLogger), closeDB, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"order-app/config"
+	"order-app/internal/kafka"
+
+	"go.uber.org/zap"
+)
+
+func runKafka(cfg *config.Config, zapLogger *zap.Logger, args []string) error {
+	if len(args) == 0 || args[0] != "replay" {
+		return fmt.Errorf("ожидалась подкоманда kafka replay\n\n%s", usage)
+	}
+
+	fs := flag.NewFlagSet("kafka replay", flag.ContinueOnError)
+	from := fs.String("from", "", "перечитать сообщения начиная с момента времени (RFC 3339)")
+	offsets := fs.String("offsets", "", "начальные смещения по партициям: 0=100,1=200")
+	progress := fs.Duration("progress", 5*time.Second, "период вывода прогресса в лог")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *progress <= 0 {
+		return fmt.Errorf("--progress должен быть больше нуля, получено %s", *progress)
+	}
+
+	var opts kafka.ReplayOptions
+	fromTime, err := timeArg("--from", *from)
+	if err != nil {
+		return err
+	}
+	if !fromTime.IsZero() {
+		opts.From = &fromTime
+	}
+	if opts.Offsets, err = parseOffsets(*offsets); err != nil {
+		return err
+	}
+	if err := opts.Validate(); err != nil {
+		return fmt.Errorf("%w\n\n%s", err, usage)
+	}
+
+	svc, closeDB, err := newShardedOrderService(cfg, zapLogger)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	replayer, err := kafka.NewReplayer(cfg, svc, zapLogger)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	status, err := replayer.Run(ctx, opts, *progress, func(st kafka.ReplayStatus) {
+		for _, p := range st.Partitions {
+			zapLogger.Info("Прогресс перечитывания топика",
+				zap.Int("partition", p.Partition),
+				zap.Int64("current", p.Current),
+				zap.Int64("end", p.End),
+				zap.Int("processed", p.Processed),
+				zap.Int("invalid", p.Invalid),
+				zap.Int("failed", p.Failed),
+			)
+		}
+	})
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if encErr := enc.Encode(status); encErr != nil {
+		zapLogger.Error("Не удалось вывести отчёт перечитывания", zap.Error(encErr))
+	}
+	return err
+}
+
+// parseOffsets разбирает смещения в формате "партиция=смещение" через запятую.
+func parseOffsets(val string) (map[int]int64, error) {
+	if val == "" {
+		return nil, nil
+	}
+
+	offsets := make(map[int]int64)
+	for _, pair := range strings.Split(val, ",") {
+		p, off, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		partition, pErr := strconv.Atoi(p)
+		offset, oErr := strconv.ParseInt(off, 10, 64)
+		if !ok || pErr != nil || oErr != nil {
+			return nil, fmt.Errorf("--offsets: ожидалось партиция=смещение, получено %q", pair)
+		}
+		if _, dup := offsets[partition]; dup {
+			return nil, fmt.Errorf("--offsets: партиция %d указана дважды", partition)
+		}
+		offsets[partition] = offset
+	}
+	return offsets, nil
+}
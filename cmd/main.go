@@ -1,105 +1,87 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
 	"order-app/config"
-	"order-app/db"
-	"order-app/internal/cache"
-	"order-app/internal/handler"
-	"order-app/internal/kafka"
 	"order-app/internal/logger"
-	"order-app/internal/repository"
-	"order-app/internal/service"
 
-	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
 )
 
+const usage = `Использование:
+  main [serve] [--skip-migrate]
+  main migrate up
+  main migrate down N
+  main migrate status
+  main migrate force V
+  main migrate goto V
+  main pii reencrypt [--batch N] [--dry-run]
+  main kafka replay (--from T | --offsets P=OFFSET,...) [--progress 5s]
+  main import [--dry-run] [--concurrency N] FILE...
+  main export [--format ndjson|csv] [--out FILE] [--gzip] [--delivery-service S] [--customer-id C] [--from T] [--to T]
+`
+
+// commandScopes — части конфигурации, которые использует каждая команда; их и
+// проверяет Validate перед запуском.
+var commandScopes = map[string]config.Scope{
+	"serve":   config.ScopeDB | config.ScopeKafka | config.ScopeHTTP | config.ScopePII,
+	"migrate": config.ScopeDB,
+	"pii":     config.ScopeDB | config.ScopePII,
+	"kafka":   config.ScopeDB | config.ScopeKafka | config.ScopePII,
+	"import":  config.ScopeDB | config.ScopePII,
+	"export":  config.ScopeDB | config.ScopePII,
+}
+
 func main() {
 	if err := godotenv.Load("config.env"); err != nil {
 		log.Println("Файл config.env не найден. Будут использованы значения по умолчанию.")
 	}
 
-	zapLogger, err := logger.NewZapLogger()
-	if err != nil {
-		log.Fatalf("Ошибка инициализации логгера: %v", err)
+	command, args := "serve", []string(nil)
+	if len(os.Args) > 1 {
+		command, args = os.Args[1], os.Args[2:]
 	}
-	defer zapLogger.Sync()
 
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		zapLogger.Fatal("Не удалось загрузить конфигурацию", zap.Error(err))
+		log.Fatalf("Не удалось загрузить конфигурацию: %v", err)
 	}
-
-	if err := db.RunMigrations(cfg); err != nil {
-		zapLogger.Fatal("Не удалось использовать миграции", zap.Error(err))
+	if err := cfg.Validate(commandScopes[command]); err != nil {
+		log.Fatalf("Не удалось загрузить конфигурацию: %v", err)
 	}
 
-	pool, err := config.InitDB(cfg)
+	zapLogger, logLevel, err := logger.NewZapLogger(cfg)
 	if err != nil {
-		zapLogger.Fatal("Не удалось подключиться к базе данных", zap.Error(err))
+		log.Fatalf("Ошибка инициализации логгера: %v", err)
 	}
-	defer pool.Close()
-
-	cacheStorage := cache.NewCache(24 * time.Hour)
-
-	repo := repository.NewOrderRepository(pool)
-
-	svc := service.NewOrderService(repo, cacheStorage)
+	defer zapLogger.Sync()
 
-	if loadedCount, err := svc.RestoreCache(); err != nil {
-		zapLogger.Error("Не удалось загрузить данные из БД в кэш", zap.Error(err))
-	} else {
-		zapLogger.Info("Данные из БД успешно загружены в кэш. Количество загруженных заказов: " + fmt.Sprintf("%d", loadedCount))
+	switch command {
+	case "serve":
+		err = runServe(cfg, zapLogger, logLevel, args)
+	case "migrate":
+		err = runMigrate(cfg, zapLogger, args)
+	case "pii":
+		err = runPII(cfg, zapLogger, args)
+	case "kafka":
+		err = runKafka(cfg, zapLogger, args)
+	case "import":
+		err = runImport(cfg, zapLogger, args)
+	case "export":
+		err = runExport(cfg, zapLogger, args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "Неизвестная команда %q\n\n%s", command, usage)
+		os.Exit(2)
 	}
 
-	consumer, err := kafka.NewConsumer(cfg, svc, zapLogger)
 	if err != nil {
-		zapLogger.Fatal("Не удалось создать consumer для Kafka", zap.Error(err))
+		zapLogger.Fatal("Команда завершилась с ошибкой", zap.String("command", command), zap.Error(err))
 	}
-	consumerCtx, consumerCancel := context.WithCancel(context.Background())
-	go consumer.Start(consumerCtx)
-
-	gin.SetMode(gin.ReleaseMode)
-
-	r := gin.Default()
-	handler.RegisterRoutes(r, svc, zapLogger)
-
-	httpServer := &http.Server{
-		Addr:    cfg.HTTPHost + ":" + cfg.HTTPPort,
-		Handler: r,
-	}
-
-	go func() {
-		zapLogger.Info("HTTP-сервер успешно запущен по адресу " + httpServer.Addr)
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			zapLogger.Fatal("Ошибка HTTP сервера", zap.Error(err))
-		}
-	}()
-
-	stopChan := make(chan os.Signal, 1)
-	signal.Notify(stopChan, syscall.SIGTERM, syscall.SIGINT)
-
-	sig := <-stopChan
-	zapLogger.Info("Получен сигнал для завершения работы", zap.String("сигнал", sig.String()))
-
-	consumerCancel()
-	consumer.Stop()
-
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := httpServer.Shutdown(shutdownCtx); err != nil {
-		zapLogger.Error("Не удалось корректно завершить работу HTTP сервера", zap.Error(err))
-	}
-
-	zapLogger.Info("Сервис успешно завершил работу")
 }
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"order-app/config"
+	"order-app/db"
+
+	"go.uber.org/zap"
+)
+
+func runMigrate(cfg *config.Config, zapLogger *zap.Logger, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("не указана подкоманда migrate\n\n%s", usage)
+	}
+	switch args[0] {
+	case "up", "down", "goto", "force", "status":
+	default:
+		return fmt.Errorf("неизвестная подкоманда migrate %q\n\n%s", args[0], usage)
+	}
+
+	m, err := db.NewMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch args[0] {
+	case "up":
+		err = m.Up()
+	case "down":
+		var steps int
+		if steps, err = intArg(args, "N"); err == nil {
+			err = m.Down(steps)
+		}
+	case "goto":
+		var version int
+		if version, err = intArg(args, "V"); err == nil {
+			if version < 0 {
+				return fmt.Errorf("версия не может быть отрицательной: %d", version)
+			}
+			err = m.Goto(uint(version))
+		}
+	case "force":
+		var version int
+		if version, err = intArg(args, "V"); err == nil {
+			err = m.Force(version)
+		}
+	case "status":
+		return printMigrationStatus(m)
+	}
+	if err != nil {
+		return err
+	}
+
+	zapLogger.Info("Команда migrate успешно выполнена", zap.Strings("args", args))
+	return printMigrationStatus(m)
+}
+
+func printMigrationStatus(m *db.Migrator) error {
+	statuses, err := m.Status()
+	if err != nil {
+		return err
+	}
+	for _, st := range statuses {
+		switch {
+		case !st.Applied:
+			fmt.Printf("шард #%d: миграции не применялись\n", st.Shard)
+		case st.Dirty:
+			fmt.Printf("шард #%d: версия %d (dirty, требуется migrate force)\n", st.Shard, st.Version)
+		default:
+			fmt.Printf("шард #%d: версия %d\n", st.Shard, st.Version)
+		}
+	}
+	return nil
+}
+
+func intArg(args []string, name string) (int, error) {
+	if len(args) < 2 {
+		return 0, fmt.Errorf("migrate %s: не указан аргумент %s", args[0], name)
+	}
+	n, err := strconv.Atoi(args[1])
+	if err != nil {
+		return 0, fmt.Errorf("migrate %s: некорректный аргумент %s=%q: %w", args[0], name, args[1], err)
+	}
+	return n, nil
+}
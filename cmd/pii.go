@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"order-app/config"
+	"order-app/internal/fieldcrypt"
+	"order-app/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+func runPII(cfg *config.Config, zapLogger *zap.Logger, args []string) error {
+	if len(args) == 0 || args[0] != "reencrypt" {
+		return fmt.Errorf("ожидалась подкоманда pii reencrypt\n\n%s", usage)
+	}
+
+	fs := flag.NewFlagSet("pii reencrypt", flag.ContinueOnError)
+	batch := fs.Int("batch", 500, "количество заказов, обрабатываемых за один запрос")
+	dryRun := fs.Bool("dry-run", false, "только подсчитать заказы, требующие перешифрования")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *batch <= 0 {
+		return fmt.Errorf("--batch должен быть больше нуля, получено %d", *batch)
+	}
+
+	keyring, err := fieldcrypt.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	if keyring == nil {
+		return errors.New("ключи шифрования не заданы: укажите PII_KEYS_FILE или PII_KEYS")
+	}
+
+	pool, err := config.InitDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	shardPools, err := config.InitShardDBs(cfg)
+	if err != nil {
+		return err
+	}
+	for _, p := range shardPools {
+		defer p.Close()
+	}
+
+	ctx := context.Background()
+	for i, p := range append([]*pgxpool.Pool{pool}, shardPools...) {
+		stats, err := repository.NewOrderRepository(p).WithEncryption(keyring).ReencryptPII(ctx, *batch, *dryRun)
+		if err != nil {
+			return fmt.Errorf("шард #%d: %w", i, err)
+		}
+		zapLogger.Info("Перешифрование персональных данных завершено",
+			zap.Int("shard", i),
+			zap.String("primary_key", keyring.PrimaryID()),
+			zap.Int("scanned", stats.Scanned),
+			zap.Int("updated", stats.Updated),
+			zap.Bool("dry_run", *dryRun),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"order-app/config"
+	"order-app/db"
+	"order-app/internal/auth"
+	"order-app/internal/cache"
+	"order-app/internal/fieldcrypt"
+	"order-app/internal/grpcapi"
+	"order-app/internal/handler"
+	"order-app/internal/health"
+	"order-app/internal/kafka"
+	"order-app/internal/metrics"
+	"order-app/internal/openapi"
+	"order-app/internal/ratelimit"
+	"order-app/internal/repository"
+	"order-app/internal/service"
+	"order-app/internal/tracing"
+	"order-app/internal/web"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.uber.org/zap"
+)
+
+func runServe(cfg *config.Config, zapLogger *zap.Logger, logLevel zap.AtomicLevel, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	skipMigrate := fs.Bool("skip-migrate", !cfg.MigrateOnStart, "не применять миграции при запуске")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *skipMigrate {
+		zapLogger.Info("Автоматическое применение миграций отключено")
+	} else if err := db.RunMigrations(cfg); err != nil {
+		zapLogger.Fatal("Не удалось использовать миграции", zap.Error(err))
+	}
+
+	shutdownTracing, err := tracing.Init(context.Background(), cfg)
+	if err != nil {
+		zapLogger.Fatal("Не удалось инициализировать трассировку", zap.Error(err))
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			zapLogger.Error("Не удалось выгрузить оставшиеся спаны", zap.Error(err))
+		}
+	}()
+
+	pool, err := config.InitDB(cfg)
+	if err != nil {
+		zapLogger.Fatal("Не удалось подключиться к базе данных", zap.Error(err))
+	}
+	defer pool.Close()
+
+	replicaPools, err := config.InitReplicaDBs(cfg)
+	if err != nil {
+		zapLogger.Fatal("Не удалось подключиться к репликам базы данных", zap.Error(err))
+	}
+	replicas := repository.NewReplicaSet(pool, replicaPools, cfg.DBReplicaMaxLag, zapLogger)
+	defer replicas.Close()
+
+	replicaCtx, replicaCancel := context.WithCancel(context.Background())
+	defer replicaCancel()
+	if len(replicaPools) > 0 {
+		go replicas.Start(replicaCtx, cfg.DBReplicaCheckPeriod)
+	}
+
+	shardPools, err := config.InitShardDBs(cfg)
+	if err != nil {
+		zapLogger.Fatal("Не удалось подключиться к шардам базы данных", zap.Error(err))
+	}
+	keyring, err := fieldcrypt.FromConfig(cfg)
+	if err != nil {
+		zapLogger.Fatal("Не удалось загрузить ключи шифрования персональных данных", zap.Error(err))
+	}
+	if keyring == nil {
+		zapLogger.Warn("Ключи шифрования не заданы, персональные данные будут храниться в открытом виде")
+	}
+
+	shardRepos := []*repository.OrderRepository{repository.NewOrderRepository(pool).WithReplicas(replicas).WithEncryption(keyring)}
+	for _, p := range shardPools {
+		defer p.Close()
+		shardRepos = append(shardRepos, repository.NewOrderRepository(p).WithEncryption(keyring))
+	}
+
+	cacheStorage := cache.NewCache(24 * time.Hour)
+
+	appMetrics := metrics.New()
+	appMetrics.MustRegister(
+		metrics.NewCacheCollector(cacheStorage),
+		metrics.NewPoolCollector(namedPools(pool, shardPools, replicaPools)),
+	)
+
+	repo := repository.NewShardedOrderRepository(shardRepos...)
+
+	svc := service.NewOrderService(repo, cacheStorage, zapLogger)
+	appMetrics.MustRegister(metrics.NewSubscribersGauge(svc.Subscribers))
+
+	go func() {
+		// Пока кэш не прогрет, сервис не готов, поэтому загрузку повторяем до успеха.
+		for delay := time.Second; ; delay = min(delay*2, 30*time.Second) {
+			loadedCount, err := svc.RestoreCache()
+			if err == nil {
+				zapLogger.Info("Данные из БД успешно загружены в кэш. Количество загруженных заказов: " + fmt.Sprintf("%d", loadedCount))
+				return
+			}
+			zapLogger.Error("Не удалось загрузить данные из БД в кэш, повторим", zap.Duration("delay", delay), zap.Error(err))
+			time.Sleep(delay)
+		}
+	}()
+
+	consumer, err := kafka.NewConsumer(cfg, svc, zapLogger, appMetrics)
+	if err != nil {
+		zapLogger.Fatal("Не удалось создать consumer для Kafka", zap.Error(err))
+	}
+	consumerCtx, consumerCancel := context.WithCancel(context.Background())
+	go consumer.Start(consumerCtx)
+
+	replayer, err := kafka.NewReplayer(cfg, svc, zapLogger)
+	if err != nil {
+		zapLogger.Fatal("Не удалось создать перечитывание топика Kafka", zap.Error(err))
+	}
+
+	checkPostgres := func(ctx context.Context) error {
+		for name, p := range namedPools(pool, shardPools, nil) {
+			if err := p.Ping(ctx); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		return nil
+	}
+	if cfg.KafkaBreakerEnabled {
+		go consumer.WatchStorage(consumerCtx, checkPostgres, cfg.KafkaBreakerInterval, int(cfg.KafkaBreakerFailures))
+	}
+
+	checker := health.NewChecker(cfg.HealthCheckTimeout)
+	checker.Register("postgres", checkPostgres)
+	checker.Register("kafka", consumer.HealthCheck)
+	checker.Register("cache", func(ctx context.Context) error {
+		if !svc.CacheWarm() {
+			return fmt.Errorf("прогрев кэша ещё не завершён")
+		}
+		return nil
+	})
+
+	var authn *auth.Authenticator
+	if cfg.AuthEnabled {
+		authn, err = auth.NewAuthenticator(auth.Options{
+			APIKeysFile: cfg.AuthAPIKeysFile,
+			JWKSFile:    cfg.AuthJWKSFile,
+			JWTIssuer:   cfg.AuthJWTIssuer,
+			JWTAudience: cfg.AuthJWTAudience,
+		})
+		if err != nil {
+			zapLogger.Fatal("Не удалось инициализировать аутентификацию", zap.Error(err))
+		}
+	} else {
+		zapLogger.Warn("Аутентификация отключена, API доступно без учётных данных")
+	}
+
+	limiter, err := ratelimit.FromConfig(cfg, pool, zapLogger)
+	if err != nil {
+		zapLogger.Fatal("Не удалось настроить ограничение частоты запросов", zap.Error(err))
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+
+	r := newRouter(cfg, svc, checker, consumer, replayer, authn, limiter, appMetrics, logLevel, zapLogger)
+
+	httpServer := &http.Server{
+		Addr:    cfg.HTTPHost + ":" + cfg.HTTPPort,
+		Handler: r,
+	}
+
+	go func() {
+		zapLogger.Info("HTTP-сервер успешно запущен по адресу " + httpServer.Addr)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			zapLogger.Fatal("Ошибка HTTP сервера", zap.Error(err))
+		}
+	}()
+
+	var grpcServer *grpcapi.Server
+	if cfg.GRPCPort != "" {
+		lis, err := net.Listen("tcp", cfg.HTTPHost+":"+cfg.GRPCPort)
+		if err != nil {
+			zapLogger.Fatal("Не удалось открыть порт gRPC", zap.Error(err))
+		}
+		grpcServer = grpcapi.NewServer(svc, authn, zapLogger)
+		go func() {
+			zapLogger.Info("gRPC-сервер успешно запущен по адресу " + lis.Addr().String())
+			if err := grpcServer.GRPC().Serve(lis); err != nil {
+				zapLogger.Fatal("Ошибка gRPC сервера", zap.Error(err))
+			}
+		}()
+	}
+
+	stopChan := make(chan os.Signal, 1)
+	signal.Notify(stopChan, syscall.SIGTERM, syscall.SIGINT)
+
+	sig := <-stopChan
+	zapLogger.Info("Получен сигнал для завершения работы", zap.String("сигнал", sig.String()))
+	checker.SetShuttingDown()
+
+	// Даём балансировщику время увидеть /readyz=not_ready, прежде чем перестать
+	// принимать запросы и читать из Kafka.
+	if cfg.ShutdownDrainDelay > 0 {
+		zapLogger.Info("Ожидаем вывода из балансировки", zap.Duration("delay", cfg.ShutdownDrainDelay))
+		time.Sleep(cfg.ShutdownDrainDelay)
+	}
+
+	consumerCancel()
+	consumer.Stop()
+	replayer.Cancel()
+
+	svc.CloseSubscriptions()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		zapLogger.Error("Не удалось корректно завершить работу HTTP сервера", zap.Error(err))
+	}
+	if grpcServer != nil {
+		grpcServer.Shutdown(shutdownCtx)
+	}
+
+	zapLogger.Info("Сервис успешно завершил работу")
+	return nil
+}
+
+// newRouter собирает HTTP API сервиса: её же проверяет контрактный тест.
+func newRouter(
+	cfg *config.Config,
+	svc *service.OrderService,
+	checker *health.Checker,
+	consumer *kafka.Consumer,
+	replayer *kafka.Replayer,
+	authn *auth.Authenticator,
+	limiter *ratelimit.Limiter,
+	appMetrics *metrics.Metrics,
+	logLevel zap.AtomicLevel,
+	zapLogger *zap.Logger,
+) *gin.Engine {
+	r := gin.New()
+	r.Use(
+		otelgin.Middleware(cfg.TracingServiceName),
+		handler.RequestID(zapLogger),
+		handler.AccessLog(zapLogger),
+		handler.Recovery(zapLogger),
+		appMetrics.GinMiddleware(),
+	)
+	r.GET("/metrics", gin.WrapH(appMetrics.Handler()))
+	admin := r.Group("/admin", limiter.Limit(), authn.Identify(), authn.Require(auth.ScopeOrdersAdmin))
+	admin.GET("/log-level", handler.LogLevel(logLevel))
+	admin.PUT("/log-level", handler.LogLevel(logLevel))
+	handler.NewReplayHandler(replayer, zapLogger).Register(admin)
+	handler.NewConsumerHandler(consumer).Register(admin)
+	handler.RegisterRoutes(r, svc, checker, authn, limiter, zapLogger)
+	web.Register(r)
+	openapi.Register(r)
+	return r
+}
+
+func namedPools(primary *pgxpool.Pool, shards, replicas []*pgxpool.Pool) map[string]*pgxpool.Pool {
+	pools := map[string]*pgxpool.Pool{"shard-0": primary}
+	for i, p := range shards {
+		pools[fmt.Sprintf("shard-%d", i+1)] = p
+	}
+	for i, p := range replicas {
+		pools[fmt.Sprintf("replica-%d", i)] = p
+	}
+	return pools
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"order-app/config"
+	"order-app/internal/cache"
+	"order-app/internal/health"
+	"order-app/internal/kafka"
+	"order-app/internal/metrics"
+	"order-app/internal/openapi/contracttest"
+	"order-app/internal/ratelimit"
+	"order-app/internal/repository"
+	"order-app/internal/repository/storetest"
+	"order-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// TestRouterContract прогоняет контрактные проверки по маршрутизатору, который
+// собирает serve, поверх хранилища в памяти. Kafka в тесте недоступна: брокер
+// указывает на закрытый порт, а consumer не запускается.
+func TestRouterContract(t *testing.T) {
+	t.Setenv("KAFKA_BROKERS", "127.0.0.1:1")
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	log := zap.NewNop()
+
+	repo := repository.NewMemoryOrderRepository()
+	order := storetest.NewOrder("contract-order")
+	if _, err := repo.SaveOrder(context.Background(), order); err != nil {
+		t.Fatalf("SaveOrder: %v", err)
+	}
+	svc := service.NewOrderService(repo, cache.NewCache(time.Minute), log)
+
+	appMetrics := metrics.New()
+	consumer, err := kafka.NewConsumer(cfg, svc, log, appMetrics)
+	if err != nil {
+		t.Fatalf("NewConsumer: %v", err)
+	}
+	replayer, err := kafka.NewReplayer(cfg, svc, log)
+	if err != nil {
+		t.Fatalf("NewReplayer: %v", err)
+	}
+
+	checker := health.NewChecker(time.Second)
+	checker.Register("kafka", consumer.HealthCheck)
+
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(time.Minute), ratelimit.Limit{Rate: 1000, Burst: 1000}, nil, log)
+
+	gin.SetMode(gin.TestMode)
+	r := newRouter(cfg, svc, checker, consumer, replayer, nil, limiter, appMetrics, zap.NewAtomicLevel(), log)
+
+	contracttest.Run(t, r, contracttest.Cases(order.OrderUID))
+}
@@ -1,37 +1,202 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
 	HTTPHost       string
 	HTTPPort       string
+	GRPCPort       string
 	DBHost         string
 	DBPort         string
 	DBUser         string
 	DBPassword     string
 	DBName         string
-	KafkaBrokers   string
+	KafkaBrokers   []string
 	KafkaTopic     string
 	KafkaGroupID   string
 	MigrationsPath string
+	MigrateOnStart bool
+
+	KafkaReadyMaxLag   int64
+	HealthCheckTimeout time.Duration
+	ShutdownDrainDelay time.Duration
+
+	KafkaBreakerEnabled  bool
+	KafkaBreakerInterval time.Duration
+	KafkaBreakerFailures int32
+
+	KafkaClientID       string
+	KafkaTLSEnabled     bool
+	KafkaTLSCAFile      string
+	KafkaTLSCertFile    string
+	KafkaTLSKeyFile     string
+	KafkaTLSSkipVerify  bool
+	KafkaSASLMechanism  string
+	KafkaSASLUsername   string
+	KafkaSASLPassword   string
+	KafkaMinBytes       int32
+	KafkaMaxBytes       int32
+	KafkaMaxWait        time.Duration
+	KafkaStartOffset    string
+	KafkaSessionTimeout time.Duration
+
+	LogLevel              string
+	LogFormat             string
+	LogSamplingInitial    int32
+	LogSamplingThereafter int32
+	LogFile               string
+	LogFileMaxSizeMB      int32
+	LogFileMaxBackups     int32
+	LogFileMaxAgeDays     int32
+	LogFileCompress       bool
+	LogRedactPII          bool
+
+	AuthEnabled     bool
+	AuthAPIKeysFile string
+	AuthJWKSFile    string
+	AuthJWTIssuer   string
+	AuthJWTAudience string
+
+	RateLimitEnabled bool
+	RateLimitBackend string
+	RateLimitDefault string
+	RateLimitRoutes  string
+
+	PIIKeysFile   string
+	PIIKeys       string
+	PIIPrimaryKey string
+
+	TracingExporter     string
+	TracingServiceName  string
+	TracingOTLPEndpoint string
+	TracingOTLPInsecure bool
+	TracingSampleRatio  float64
+
+	DatabaseURL        string
+	DBSSLMode          string
+	DBSSLRootCert      string
+	DBSSLCert          string
+	DBSSLKey           string
+	DBApplicationName  string
+	DBStatementTimeout time.Duration
+	DBConnectTimeout   time.Duration
+
+	DBMaxConns          int32
+	DBMinConns          int32
+	DBMaxConnLifetime   time.Duration
+	DBMaxConnIdleTime   time.Duration
+	DBHealthCheckPeriod time.Duration
+
+	DBShardDSNs          []string
+	DBReplicaDSNs        []string
+	DBReplicaMaxLag      time.Duration
+	DBReplicaCheckPeriod time.Duration
 }
 
 func LoadConfig() (*Config, error) {
+	env := &envParser{}
+
 	cfg := &Config{
 		HTTPHost:       getEnv("HTTP_HOST", "0.0.0.0"),
 		HTTPPort:       getEnv("HTTP_PORT", "8080"),
+		GRPCPort:       getEnv("GRPC_PORT", "9090"),
 		DBHost:         getEnv("DB_HOST", "localhost"),
 		DBPort:         getEnv("DB_PORT", "5432"),
 		DBUser:         getEnv("DB_USER", "postgres"),
 		DBPassword:     getEnv("DB_PASSWORD", "1"),
 		DBName:         getEnv("DB_NAME", "orders_db"),
-		KafkaBrokers:   getEnv("KAFKA_BROKERS", "localhost:9092"),
+		KafkaBrokers:   splitList(getEnv("KAFKA_BROKERS", "localhost:9092")),
 		KafkaTopic:     getEnv("KAFKA_TOPIC", "orders"),
 		KafkaGroupID:   getEnv("KAFKA_GROUP_ID", "orders_consumer_group"),
-		MigrationsPath: getEnv("MIGRATIONS_PATH", "db/migrations"),
+		MigrationsPath: getEnv("MIGRATIONS_PATH", ""),
+		MigrateOnStart: env.bool("MIGRATE_ON_START", "true"),
+
+		KafkaReadyMaxLag:   env.int64("KAFKA_READY_MAX_LAG", "0"),
+		HealthCheckTimeout: env.duration("HEALTH_CHECK_TIMEOUT", "2s"),
+		ShutdownDrainDelay: env.duration("SHUTDOWN_DRAIN_DELAY", "5s"),
+
+		KafkaBreakerEnabled:  env.bool("KAFKA_BREAKER_ENABLED", "true"),
+		KafkaBreakerInterval: env.duration("KAFKA_BREAKER_INTERVAL", "5s"),
+		KafkaBreakerFailures: env.int32("KAFKA_BREAKER_FAILURES", "3"),
+
+		KafkaClientID:       getEnv("KAFKA_CLIENT_ID", "order-app"),
+		KafkaTLSEnabled:     env.bool("KAFKA_TLS_ENABLED", "false"),
+		KafkaTLSCAFile:      getEnv("KAFKA_TLS_CA_FILE", ""),
+		KafkaTLSCertFile:    getEnv("KAFKA_TLS_CERT_FILE", ""),
+		KafkaTLSKeyFile:     getEnv("KAFKA_TLS_KEY_FILE", ""),
+		KafkaTLSSkipVerify:  env.bool("KAFKA_TLS_SKIP_VERIFY", "false"),
+		KafkaSASLMechanism:  strings.ToUpper(getEnv("KAFKA_SASL_MECHANISM", "")),
+		KafkaSASLUsername:   getEnv("KAFKA_SASL_USERNAME", ""),
+		KafkaSASLPassword:   getEnv("KAFKA_SASL_PASSWORD", ""),
+		KafkaMinBytes:       env.int32("KAFKA_MIN_BYTES", "10000"),
+		KafkaMaxBytes:       env.int32("KAFKA_MAX_BYTES", "10000000"),
+		KafkaMaxWait:        env.duration("KAFKA_MAX_WAIT", "10s"),
+		KafkaStartOffset:    getEnv("KAFKA_START_OFFSET", "first"),
+		KafkaSessionTimeout: env.duration("KAFKA_SESSION_TIMEOUT", "30s"),
+
+		LogLevel:              getEnv("LOG_LEVEL", "info"),
+		LogFormat:             getEnv("LOG_FORMAT", "json"),
+		LogSamplingInitial:    env.int32("LOG_SAMPLING_INITIAL", "100"),
+		LogSamplingThereafter: env.int32("LOG_SAMPLING_THEREAFTER", "100"),
+		LogFile:               getEnv("LOG_FILE", ""),
+		LogFileMaxSizeMB:      env.int32("LOG_FILE_MAX_SIZE_MB", "100"),
+		LogFileMaxBackups:     env.int32("LOG_FILE_MAX_BACKUPS", "5"),
+		LogFileMaxAgeDays:     env.int32("LOG_FILE_MAX_AGE_DAYS", "30"),
+		LogFileCompress:       env.bool("LOG_FILE_COMPRESS", "true"),
+		LogRedactPII:          env.bool("LOG_REDACT_PII", "true"),
+
+		AuthEnabled:     env.bool("AUTH_ENABLED", "true"),
+		AuthAPIKeysFile: getEnv("AUTH_API_KEYS_FILE", ""),
+		AuthJWKSFile:    getEnv("AUTH_JWKS_FILE", ""),
+		AuthJWTIssuer:   getEnv("AUTH_JWT_ISSUER", ""),
+		AuthJWTAudience: getEnv("AUTH_JWT_AUDIENCE", ""),
+
+		RateLimitEnabled: env.bool("RATE_LIMIT_ENABLED", "true"),
+		RateLimitBackend: getEnv("RATE_LIMIT_BACKEND", "memory"),
+		RateLimitDefault: getEnv("RATE_LIMIT_DEFAULT", "10:20"),
+		RateLimitRoutes:  getEnv("RATE_LIMIT_ROUTES", ""),
+
+		PIIKeysFile:   getEnv("PII_KEYS_FILE", ""),
+		PIIKeys:       getEnv("PII_KEYS", ""),
+		PIIPrimaryKey: getEnv("PII_PRIMARY_KEY", ""),
+
+		TracingExporter:     getEnv("TRACING_EXPORTER", "none"),
+		TracingServiceName:  getEnv("TRACING_SERVICE_NAME", "order-app"),
+		TracingOTLPEndpoint: getEnv("TRACING_OTLP_ENDPOINT", ""),
+		TracingOTLPInsecure: env.bool("TRACING_OTLP_INSECURE", "true"),
+		TracingSampleRatio:  env.float64("TRACING_SAMPLE_RATIO", "1"),
+
+		DatabaseURL:        getEnv("DATABASE_URL", ""),
+		DBSSLMode:          getEnv("DB_SSLMODE", "disable"),
+		DBSSLRootCert:      getEnv("DB_SSLROOTCERT", ""),
+		DBSSLCert:          getEnv("DB_SSLCERT", ""),
+		DBSSLKey:           getEnv("DB_SSLKEY", ""),
+		DBApplicationName:  getEnv("DB_APPLICATION_NAME", "order-app"),
+		DBStatementTimeout: env.duration("DB_STATEMENT_TIMEOUT", "0s"),
+		DBConnectTimeout:   env.duration("DB_CONNECT_TIMEOUT", "5s"),
+
+		DBMaxConns:          env.int32("DB_MAX_CONNS", "20"),
+		DBMinConns:          env.int32("DB_MIN_CONNS", "5"),
+		DBMaxConnLifetime:   env.duration("DB_MAX_CONN_LIFETIME", "2h"),
+		DBMaxConnIdleTime:   env.duration("DB_MAX_CONN_IDLE_TIME", "10m"),
+		DBHealthCheckPeriod: env.duration("DB_HEALTH_CHECK_PERIOD", "1m"),
+
+		DBShardDSNs:          splitList(getEnv("DB_SHARD_DSNS", "")),
+		DBReplicaDSNs:        splitList(getEnv("DB_REPLICA_DSNS", "")),
+		DBReplicaMaxLag:      env.duration("DB_REPLICA_MAX_LAG", "10s"),
+		DBReplicaCheckPeriod: env.duration("DB_REPLICA_CHECK_PERIOD", "5s"),
+	}
+
+	if err := env.err(); err != nil {
+		return nil, err
 	}
 
 	return cfg, nil
@@ -45,3 +210,66 @@ func getEnv(key, defaultVal string) string {
 	}
 	return val
 }
+
+type envParser struct {
+	errs []error
+}
+
+func (p *envParser) duration(key, defaultVal string) time.Duration {
+	val := getEnv(key, defaultVal)
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("некорректное значение %s=%q: %w", key, val, err))
+	}
+	return d
+}
+
+func (p *envParser) int32(key, defaultVal string) int32 {
+	val := getEnv(key, defaultVal)
+	n, err := strconv.ParseInt(val, 10, 32)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("некорректное значение %s=%q: %w", key, val, err))
+	}
+	return int32(n)
+}
+
+func (p *envParser) int64(key, defaultVal string) int64 {
+	val := getEnv(key, defaultVal)
+	n, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("некорректное значение %s=%q: %w", key, val, err))
+	}
+	return n
+}
+
+func (p *envParser) float64(key, defaultVal string) float64 {
+	val := getEnv(key, defaultVal)
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("некорректное значение %s=%q: %w", key, val, err))
+	}
+	return f
+}
+
+func (p *envParser) bool(key, defaultVal string) bool {
+	val := getEnv(key, defaultVal)
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("некорректное значение %s=%q: %w", key, val, err))
+	}
+	return b
+}
+
+func (p *envParser) err() error {
+	return errors.Join(p.errs...)
+}
+
+func splitList(val string) []string {
+	var out []string
+	for _, part := range strings.Split(val, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -3,34 +3,94 @@ package config
 import (
 	"context"
 	"fmt"
-	"time"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
 
+	"github.com/exaring/otelpgx"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func InitDB(cfg *Config) (*pgxpool.Pool, error) {
-	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName)
+func BuildDSN(cfg *Config) string {
+	if cfg.DatabaseURL != "" {
+		return cfg.DatabaseURL
+	}
 
-	poolConfig, err := pgxpool.ParseConfig(dsn)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка парсинга конфигурации пула соединений: %w", err)
+	query := url.Values{}
+	query.Set("sslmode", cfg.DBSSLMode)
+	if cfg.DBSSLRootCert != "" {
+		query.Set("sslrootcert", cfg.DBSSLRootCert)
+	}
+	if cfg.DBSSLCert != "" {
+		query.Set("sslcert", cfg.DBSSLCert)
+		query.Set("sslkey", cfg.DBSSLKey)
+	}
+	if cfg.DBApplicationName != "" {
+		query.Set("application_name", cfg.DBApplicationName)
+	}
+	if cfg.DBConnectTimeout > 0 {
+		// connect_timeout задаётся в целых секундах, а 0 означает «ждать бесконечно»,
+		// поэтому доли секунды округляются вверх.
+		query.Set("connect_timeout", strconv.Itoa(int(math.Ceil(cfg.DBConnectTimeout.Seconds()))))
+	}
+	if cfg.DBStatementTimeout > 0 {
+		query.Set("statement_timeout", strconv.FormatInt(cfg.DBStatementTimeout.Milliseconds(), 10))
 	}
 
-	poolConfig.MaxConns = 20
-	poolConfig.MinConns = 5
-	poolConfig.MaxConnLifetime = 2 * time.Hour
-	poolConfig.MaxConnIdleTime = 10 * time.Minute
-	poolConfig.HealthCheckPeriod = 1 * time.Minute
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.DBUser, cfg.DBPassword),
+		Host:     net.JoinHostPort(cfg.DBHost, cfg.DBPort),
+		Path:     "/" + cfg.DBName,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+func InitDB(cfg *Config) (*pgxpool.Pool, error) {
+	return initPingedPool(cfg, BuildDSN(cfg))
+}
+
+func InitShardDBs(cfg *Config) ([]*pgxpool.Pool, error) {
+	pools := make([]*pgxpool.Pool, 0, len(cfg.DBShardDSNs))
+	for i, dsn := range cfg.DBShardDSNs {
+		pool, err := initPingedPool(cfg, dsn)
+		if err != nil {
+			for _, p := range pools {
+				p.Close()
+			}
+			return nil, fmt.Errorf("шард #%d: %w", i+1, err)
+		}
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
+
+func InitReplicaDBs(cfg *Config) ([]*pgxpool.Pool, error) {
+	pools := make([]*pgxpool.Pool, 0, len(cfg.DBReplicaDSNs))
+	for i, dsn := range cfg.DBReplicaDSNs {
+		pool, err := newPool(cfg, dsn)
+		if err != nil {
+			for _, p := range pools {
+				p.Close()
+			}
+			return nil, fmt.Errorf("реплика #%d: %w", i, err)
+		}
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
 
-	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+func initPingedPool(cfg *Config, dsn string) (*pgxpool.Pool, error) {
+	pool, err := newPool(cfg, dsn)
 	if err != nil {
-		return nil, fmt.Errorf("не удалось создать пул соединений: %w", err)
+		return nil, err
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DBConnectTimeout)
+	defer cancel()
+
 	if err = pool.Ping(ctx); err != nil {
 		pool.Close()
 		return nil, fmt.Errorf("не удалось достучаться до базы данных: %w", err)
@@ -38,3 +98,24 @@ func InitDB(cfg *Config) (*pgxpool.Pool, error) {
 
 	return pool, nil
 }
+
+func newPool(cfg *Config, dsn string) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга конфигурации пула соединений: %w", err)
+	}
+
+	poolConfig.MaxConns = cfg.DBMaxConns
+	poolConfig.MinConns = cfg.DBMinConns
+	poolConfig.MaxConnLifetime = cfg.DBMaxConnLifetime
+	poolConfig.MaxConnIdleTime = cfg.DBMaxConnIdleTime
+	poolConfig.HealthCheckPeriod = cfg.DBHealthCheckPeriod
+	poolConfig.ConnConfig.Tracer = otelpgx.NewTracer()
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать пул соединений: %w", err)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestBuildDSN(t *testing.T) {
+	cfg := &Config{
+		DBHost:             "db.internal",
+		DBPort:             "6432",
+		DBUser:             "order app",
+		DBPassword:         "p@ss:w/rd?#%&=",
+		DBName:             "orders",
+		DBSSLMode:          "verify-full",
+		DBSSLRootCert:      "/certs/ca.pem",
+		DBSSLCert:          "/certs/client.pem",
+		DBSSLKey:           "/certs/client.key",
+		DBApplicationName:  "order-app",
+		DBConnectTimeout:   1500 * time.Millisecond,
+		DBStatementTimeout: 2 * time.Second,
+	}
+
+	dsn := BuildDSN(cfg)
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("DSN не разбирается: %v", err)
+	}
+	if pw, _ := u.User.Password(); pw != cfg.DBPassword || u.User.Username() != cfg.DBUser {
+		t.Errorf("учётные данные искажены: %q / %q", u.User.Username(), pw)
+	}
+	if u.Host != "db.internal:6432" || u.Path != "/orders" {
+		t.Errorf("адрес базы искажён: %s%s", u.Host, u.Path)
+	}
+	for key, want := range map[string]string{
+		"sslmode":           "verify-full",
+		"sslrootcert":       "/certs/ca.pem",
+		"sslcert":           "/certs/client.pem",
+		"sslkey":            "/certs/client.key",
+		"application_name":  "order-app",
+		"connect_timeout":   "2",
+		"statement_timeout": "2000",
+	} {
+		if got := u.Query().Get(key); got != want {
+			t.Errorf("%s = %q, ожидалось %q", key, got, want)
+		}
+	}
+
+	// pgx должен прочитать тот же пароль, что был задан. Файлов сертификатов в
+	// тесте нет, поэтому TLS отключается.
+	plain := *cfg
+	plain.DBSSLMode, plain.DBSSLRootCert, plain.DBSSLCert, plain.DBSSLKey = "disable", "", "", ""
+	pc, err := pgxpool.ParseConfig(BuildDSN(&plain))
+	if err != nil {
+		t.Fatalf("pgxpool.ParseConfig: %v", err)
+	}
+	if pc.ConnConfig.Password != cfg.DBPassword || pc.ConnConfig.ConnectTimeout != 2*time.Second {
+		t.Errorf("pgx прочитал пароль %q и таймаут %s", pc.ConnConfig.Password, pc.ConnConfig.ConnectTimeout)
+	}
+}
+
+func TestBuildDSNOptionalParams(t *testing.T) {
+	dsn := BuildDSN(&Config{DBHost: "localhost", DBPort: "5432", DBUser: "postgres", DBName: "orders_db", DBSSLMode: "disable"})
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("DSN не разбирается: %v", err)
+	}
+	q := u.Query()
+	if q.Get("sslmode") != "disable" {
+		t.Errorf("sslmode = %q", q.Get("sslmode"))
+	}
+	for _, key := range []string{"sslrootcert", "sslcert", "sslkey", "application_name", "connect_timeout", "statement_timeout"} {
+		if q.Has(key) {
+			t.Errorf("незаданный параметр %s попал в DSN: %s", key, dsn)
+		}
+	}
+}
+
+func TestBuildDSNUsesDatabaseURL(t *testing.T) {
+	const dsn = "postgres://u:p@host:5432/db?sslmode=require"
+	if got := BuildDSN(&Config{DatabaseURL: dsn, DBHost: "ignored"}); got != dsn {
+		t.Errorf("BuildDSN = %q, ожидался DATABASE_URL как есть", got)
+	}
+}
@@ -5,13 +5,36 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-func NewZapLoggerConfig() zap.Config {
+func NewZapLoggerConfig(appCfg *Config) zap.Config {
 	cfg := zap.NewProductionConfig()
-	cfg.Encoding = "json"
+	cfg.Encoding = appCfg.LogFormat
 	cfg.EncoderConfig.TimeKey = "time"
 	cfg.EncoderConfig.MessageKey = "message"
 	cfg.EncoderConfig.LevelKey = "level"
 	cfg.EncoderConfig.CallerKey = "caller"
 	cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	if appCfg.LogFormat == "console" {
+		cfg.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	}
+	if appCfg.LogRedactPII {
+		cfg.Encoding = "redacted-" + appCfg.LogFormat
+	}
+
+	if level, err := zapcore.ParseLevel(appCfg.LogLevel); err == nil {
+		cfg.Level = zap.NewAtomicLevelAt(level)
+	}
+
+	cfg.Sampling = nil
+	if appCfg.LogSamplingInitial > 0 {
+		cfg.Sampling = &zap.SamplingConfig{
+			Initial:    int(appCfg.LogSamplingInitial),
+			Thereafter: int(appCfg.LogSamplingThereafter),
+		}
+	}
+
+	if appCfg.LogFile != "" {
+		cfg.OutputPaths = append(cfg.OutputPaths, "rotate://"+appCfg.LogFile)
+		cfg.ErrorOutputPaths = append(cfg.ErrorOutputPaths, "rotate://"+appCfg.LogFile)
+	}
 	return cfg
 }
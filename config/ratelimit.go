@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// RateLimit — лимит запросов: Rate запросов в секунду с пачкой до Burst.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// ParseRateLimit разбирает лимит в формате "rps:burst", например "5:10".
+func ParseRateLimit(s string) (RateLimit, error) {
+	rateStr, burstStr, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("ожидался формат rps:burst, получено %q", s)
+	}
+	rate, err := strconv.ParseFloat(rateStr, 64)
+	if err != nil || rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		return RateLimit{}, fmt.Errorf("некорректная скорость %q", rateStr)
+	}
+	burst, err := strconv.Atoi(burstStr)
+	if err != nil || burst <= 0 {
+		return RateLimit{}, fmt.Errorf("некорректный размер пачки %q", burstStr)
+	}
+	return RateLimit{Rate: rate, Burst: burst}, nil
+}
+
+// ParseRouteRateLimits разбирает лимиты в формате "GET /order/:id=5:10;POST /orders/batch=1:2".
+func ParseRouteRateLimits(s string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		route, spec, ok := strings.Cut(part, "=")
+		fields := strings.Fields(route)
+		if !ok || len(fields) != 2 {
+			return nil, fmt.Errorf("ожидался формат \"METHOD /route=rps:burst\", получено %q", part)
+		}
+		limit, err := ParseRateLimit(spec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", route, err)
+		}
+		limits[strings.Join(fields, " ")] = limit
+	}
+	return limits, nil
+}
@@ -0,0 +1,266 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var validSSLModes = map[string]bool{
+	"disable":     true,
+	"allow":       true,
+	"prefer":      true,
+	"require":     true,
+	"verify-ca":   true,
+	"verify-full": true,
+}
+
+// Scope — группы настроек, которые проверяет Validate. Команды используют разные
+// части конфигурации: migrate не нужны ни Kafka, ни аутентификация, и требовать
+// их от неё незачем. Логирование и трассировка проверяются всегда.
+type Scope uint
+
+const (
+	ScopeDB Scope = 1 << iota
+	ScopeKafka
+	ScopeHTTP
+	ScopePII
+)
+
+func (c *Config) Validate(scope Scope) error {
+	errs := append(c.validateLog(), c.validateTracing()...)
+	if scope&ScopeDB != 0 {
+		errs = append(errs, c.validateDB()...)
+	}
+	if scope&ScopeKafka != 0 {
+		errs = append(errs, c.validateKafka()...)
+	}
+	if scope&ScopeHTTP != 0 {
+		errs = append(errs, c.validateHTTP()...)
+	}
+	if scope&ScopePII != 0 {
+		errs = append(errs, c.validatePII()...)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("некорректная конфигурация: %w", err)
+	}
+	return nil
+}
+
+func (c *Config) validateDB() []error {
+	var errs []error
+
+	if c.DatabaseURL != "" {
+		if err := validateDSN(c.DatabaseURL); err != nil {
+			errs = append(errs, fmt.Errorf("DATABASE_URL: %w", err))
+		}
+		// BuildDSN берёт DATABASE_URL как есть, и настройки TLS из DB_SSL* молча
+		// потерялись бы: их нужно указывать параметрами самого DATABASE_URL.
+		if c.DBSSLMode != "disable" || c.DBSSLRootCert != "" || c.DBSSLCert != "" || c.DBSSLKey != "" {
+			errs = append(errs, errors.New("DB_SSLMODE, DB_SSLROOTCERT, DB_SSLCERT и DB_SSLKEY не применяются вместе с DATABASE_URL: укажите sslmode, sslrootcert, sslcert и sslkey в самом DATABASE_URL"))
+		}
+	} else {
+		if c.DBHost == "" || c.DBPort == "" || c.DBName == "" || c.DBUser == "" {
+			errs = append(errs, errors.New("DB_HOST, DB_PORT, DB_NAME и DB_USER должны быть заданы, если не указан DATABASE_URL"))
+		}
+		if !validSSLModes[c.DBSSLMode] {
+			errs = append(errs, fmt.Errorf("DB_SSLMODE: неизвестный режим %q", c.DBSSLMode))
+		}
+		if (c.DBSSLMode == "verify-ca" || c.DBSSLMode == "verify-full") && c.DBSSLRootCert == "" {
+			errs = append(errs, fmt.Errorf("DB_SSLMODE=%s требует DB_SSLROOTCERT", c.DBSSLMode))
+		}
+		if (c.DBSSLCert == "") != (c.DBSSLKey == "") {
+			errs = append(errs, errors.New("DB_SSLCERT и DB_SSLKEY должны задаваться вместе"))
+		}
+		for _, f := range []struct{ key, path string }{
+			{"DB_SSLROOTCERT", c.DBSSLRootCert},
+			{"DB_SSLCERT", c.DBSSLCert},
+			{"DB_SSLKEY", c.DBSSLKey},
+		} {
+			if f.path == "" {
+				continue
+			}
+			if _, err := os.Stat(f.path); err != nil {
+				errs = append(errs, fmt.Errorf("%s: файл недоступен: %w", f.key, err))
+			}
+		}
+	}
+
+	for i, dsn := range c.DBShardDSNs {
+		if err := validateDSN(dsn); err != nil {
+			errs = append(errs, fmt.Errorf("DB_SHARD_DSNS[%d]: %w", i, err))
+		}
+	}
+	for i, dsn := range c.DBReplicaDSNs {
+		if err := validateDSN(dsn); err != nil {
+			errs = append(errs, fmt.Errorf("DB_REPLICA_DSNS[%d]: %w", i, err))
+		}
+	}
+
+	if c.DBMaxConns <= 0 {
+		errs = append(errs, fmt.Errorf("DB_MAX_CONNS должен быть больше нуля, получено %d", c.DBMaxConns))
+	}
+	if c.DBMinConns < 0 || c.DBMinConns > c.DBMaxConns {
+		errs = append(errs, fmt.Errorf("DB_MIN_CONNS должен быть в диапазоне [0, DB_MAX_CONNS], получено %d", c.DBMinConns))
+	}
+	if c.DBConnectTimeout < time.Second {
+		errs = append(errs, fmt.Errorf("DB_CONNECT_TIMEOUT должен быть не меньше 1s, получено %s", c.DBConnectTimeout))
+	}
+	if c.DBStatementTimeout < 0 || c.DBMaxConnLifetime < 0 || c.DBMaxConnIdleTime < 0 {
+		errs = append(errs, errors.New("DB_STATEMENT_TIMEOUT, DB_MAX_CONN_LIFETIME и DB_MAX_CONN_IDLE_TIME не могут быть отрицательными"))
+	}
+	if c.DBHealthCheckPeriod <= 0 || c.DBReplicaCheckPeriod <= 0 {
+		errs = append(errs, errors.New("DB_HEALTH_CHECK_PERIOD и DB_REPLICA_CHECK_PERIOD должны быть больше нуля"))
+	}
+	return errs
+}
+
+func (c *Config) validateLog() []error {
+	var errs []error
+
+	if _, err := zapcore.ParseLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL: %w", err))
+	}
+	if c.LogFormat != "json" && c.LogFormat != "console" {
+		errs = append(errs, fmt.Errorf("LOG_FORMAT: ожидалось json или console, получено %q", c.LogFormat))
+	}
+	if c.LogSamplingInitial < 0 || c.LogSamplingThereafter < 0 {
+		errs = append(errs, errors.New("LOG_SAMPLING_INITIAL и LOG_SAMPLING_THEREAFTER не могут быть отрицательными"))
+	}
+	if c.LogSamplingInitial > 0 && c.LogSamplingThereafter == 0 {
+		errs = append(errs, errors.New("LOG_SAMPLING_THEREAFTER=0 отбрасывает все повторы после LOG_SAMPLING_INITIAL: задайте значение больше нуля или отключите сэмплирование через LOG_SAMPLING_INITIAL=0"))
+	}
+	if c.LogFileMaxSizeMB <= 0 || c.LogFileMaxBackups < 0 || c.LogFileMaxAgeDays < 0 {
+		errs = append(errs, errors.New("LOG_FILE_MAX_SIZE_MB должен быть больше нуля, LOG_FILE_MAX_BACKUPS и LOG_FILE_MAX_AGE_DAYS не могут быть отрицательными"))
+	}
+	return errs
+}
+
+// validateKafka проверяет подключение к Kafka и настройки consumer вместе с
+// circuit breaker, который его приостанавливает.
+func (c *Config) validateKafka() []error {
+	var errs []error
+
+	if len(c.KafkaBrokers) == 0 {
+		errs = append(errs, errors.New("KAFKA_BROKERS: не указан ни один брокер"))
+	}
+	for _, broker := range c.KafkaBrokers {
+		if _, _, err := net.SplitHostPort(broker); err != nil {
+			errs = append(errs, fmt.Errorf("KAFKA_BROKERS: ожидалось host:port, получено %q", broker))
+		}
+	}
+	if !c.KafkaTLSEnabled && (c.KafkaTLSCAFile != "" || c.KafkaTLSCertFile != "") {
+		errs = append(errs, errors.New("KAFKA_TLS_CA_FILE и KAFKA_TLS_CERT_FILE требуют KAFKA_TLS_ENABLED=true"))
+	}
+	if (c.KafkaTLSCertFile == "") != (c.KafkaTLSKeyFile == "") {
+		errs = append(errs, errors.New("KAFKA_TLS_CERT_FILE и KAFKA_TLS_KEY_FILE должны задаваться вместе"))
+	}
+	for _, f := range []struct{ key, path string }{
+		{"KAFKA_TLS_CA_FILE", c.KafkaTLSCAFile},
+		{"KAFKA_TLS_CERT_FILE", c.KafkaTLSCertFile},
+		{"KAFKA_TLS_KEY_FILE", c.KafkaTLSKeyFile},
+	} {
+		if f.path == "" {
+			continue
+		}
+		if _, err := os.Stat(f.path); err != nil {
+			errs = append(errs, fmt.Errorf("%s: файл недоступен: %w", f.key, err))
+		}
+	}
+	switch c.KafkaSASLMechanism {
+	case "":
+	case "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512":
+		if c.KafkaSASLUsername == "" {
+			errs = append(errs, fmt.Errorf("KAFKA_SASL_MECHANISM=%s требует KAFKA_SASL_USERNAME", c.KafkaSASLMechanism))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("KAFKA_SASL_MECHANISM: ожидалось PLAIN, SCRAM-SHA-256 или SCRAM-SHA-512, получено %q", c.KafkaSASLMechanism))
+	}
+	if c.KafkaMinBytes <= 0 || c.KafkaMaxBytes < c.KafkaMinBytes {
+		errs = append(errs, fmt.Errorf("KAFKA_MIN_BYTES должен быть больше нуля и не больше KAFKA_MAX_BYTES, получено %d и %d", c.KafkaMinBytes, c.KafkaMaxBytes))
+	}
+	if c.KafkaMaxWait <= 0 || c.KafkaSessionTimeout <= 0 {
+		errs = append(errs, errors.New("KAFKA_MAX_WAIT и KAFKA_SESSION_TIMEOUT должны быть больше нуля"))
+	}
+	if c.KafkaStartOffset != "first" && c.KafkaStartOffset != "last" {
+		errs = append(errs, fmt.Errorf("KAFKA_START_OFFSET: ожидалось first или last, получено %q", c.KafkaStartOffset))
+	}
+
+	if c.KafkaBreakerInterval <= 0 || c.KafkaBreakerFailures <= 0 {
+		errs = append(errs, errors.New("KAFKA_BREAKER_INTERVAL и KAFKA_BREAKER_FAILURES должны быть больше нуля"))
+	}
+	return errs
+}
+
+// validateHTTP проверяет настройки, нужные только serve: аутентификацию, лимиты
+// запросов и остановку.
+func (c *Config) validateHTTP() []error {
+	var errs []error
+
+	if c.ShutdownDrainDelay < 0 {
+		errs = append(errs, errors.New("SHUTDOWN_DRAIN_DELAY не может быть отрицательным"))
+	}
+
+	if c.AuthEnabled && c.AuthAPIKeysFile == "" && c.AuthJWKSFile == "" {
+		errs = append(errs, errors.New("AUTH_ENABLED=true требует AUTH_API_KEYS_FILE и/или AUTH_JWKS_FILE"))
+	}
+
+	if c.RateLimitBackend != "memory" && c.RateLimitBackend != "postgres" {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_BACKEND: ожидалось memory или postgres, получено %q", c.RateLimitBackend))
+	}
+	if c.RateLimitEnabled {
+		if _, err := ParseRateLimit(c.RateLimitDefault); err != nil {
+			errs = append(errs, fmt.Errorf("RATE_LIMIT_DEFAULT: %w", err))
+		}
+		if _, err := ParseRouteRateLimits(c.RateLimitRoutes); err != nil {
+			errs = append(errs, fmt.Errorf("RATE_LIMIT_ROUTES: %w", err))
+		}
+	}
+	return errs
+}
+
+func (c *Config) validatePII() []error {
+	var errs []error
+
+	if c.PIIKeysFile != "" && c.PIIKeys != "" {
+		errs = append(errs, errors.New("PII_KEYS_FILE и PII_KEYS взаимоисключающие"))
+	}
+	if c.PIIKeys != "" && c.PIIPrimaryKey == "" {
+		errs = append(errs, errors.New("PII_KEYS требует PII_PRIMARY_KEY"))
+	}
+	return errs
+}
+
+func (c *Config) validateTracing() []error {
+	var errs []error
+
+	switch c.TracingExporter {
+	case "none", "stdout", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("TRACING_EXPORTER: ожидалось none, stdout или otlp, получено %q", c.TracingExporter))
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("TRACING_SAMPLE_RATIO должен быть в диапазоне [0, 1], получено %v", c.TracingSampleRatio))
+	}
+	return errs
+}
+
+func validateDSN(dsn string) error {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return fmt.Errorf("не удалось разобрать DSN: %w", err)
+	}
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return fmt.Errorf("ожидалась схема postgres:// или postgresql://, получено %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return errors.New("в DSN не указан хост")
+	}
+	return nil
+}
@@ -0,0 +1,339 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// validConfig возвращает конфигурацию по умолчанию, проходящую все проверки.
+func validConfig(t *testing.T) *Config {
+	t.Helper()
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	cfg.AuthEnabled = false
+	return cfg
+}
+
+func TestValidateScopes(t *testing.T) {
+	all := ScopeDB | ScopeKafka | ScopeHTTP | ScopePII
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		scope  Scope
+		want   string
+	}{
+		{name: "defaults", modify: func(*Config) {}, scope: all},
+		{
+			name:   "migrate ignores auth",
+			modify: func(c *Config) { c.AuthEnabled = true },
+			scope:  ScopeDB,
+		},
+		{
+			name:   "serve requires auth files",
+			modify: func(c *Config) { c.AuthEnabled = true },
+			scope:  all,
+			want:   "AUTH_ENABLED=true",
+		},
+		{
+			name:   "migrate ignores kafka",
+			modify: func(c *Config) { c.KafkaBrokers = nil },
+			scope:  ScopeDB,
+		},
+		{
+			name:   "replay requires kafka",
+			modify: func(c *Config) { c.KafkaBrokers = nil },
+			scope:  ScopeDB | ScopeKafka | ScopePII,
+			want:   "KAFKA_BROKERS",
+		},
+		{
+			name:   "logging is always checked",
+			modify: func(c *Config) { c.LogFormat = "xml" },
+			scope:  ScopeDB,
+			want:   "LOG_FORMAT",
+		},
+		{
+			name:   "db is checked for migrate",
+			modify: func(c *Config) { c.DBConnectTimeout = 0 },
+			scope:  ScopeDB,
+			want:   "DB_CONNECT_TIMEOUT",
+		},
+		{
+			name:   "pii keys require primary",
+			modify: func(c *Config) { c.PIIKeys = "k1:AAAA" },
+			scope:  ScopeDB | ScopePII,
+			want:   "PII_PRIMARY_KEY",
+		},
+		{
+			name:   "bad default rate limit",
+			modify: func(c *Config) { c.RateLimitDefault = "10" },
+			scope:  ScopeHTTP,
+			want:   "RATE_LIMIT_DEFAULT",
+		},
+		{
+			name:   "bad route rate limit",
+			modify: func(c *Config) { c.RateLimitRoutes = "GET /order/:id=5:0" },
+			scope:  ScopeHTTP,
+			want:   "RATE_LIMIT_ROUTES",
+		},
+		{
+			name: "disabled rate limit is not parsed",
+			modify: func(c *Config) {
+				c.RateLimitEnabled = false
+				c.RateLimitRoutes = "garbage"
+			},
+			scope: ScopeHTTP,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig(t)
+			tt.modify(cfg)
+			err := cfg.Validate(tt.scope)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Validate = %v, ожидалась ошибка про %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateKafka(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.pem")
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   string
+	}{
+		{name: "defaults", modify: func(*Config) {}},
+		{
+			name:   "several brokers",
+			modify: func(c *Config) { c.KafkaBrokers = []string{"kafka-1:9092", "[::1]:9093"} },
+		},
+		{
+			name:   "no brokers",
+			modify: func(c *Config) { c.KafkaBrokers = nil },
+			want:   "не указан ни один брокер",
+		},
+		{
+			name:   "broker without port",
+			modify: func(c *Config) { c.KafkaBrokers = []string{"kafka-1:9092", "kafka-2"} },
+			want:   `получено "kafka-2"`,
+		},
+		{
+			name: "tls files",
+			modify: func(c *Config) {
+				c.KafkaTLSEnabled = true
+				c.KafkaTLSCAFile = file
+				c.KafkaTLSCertFile = file
+				c.KafkaTLSKeyFile = file
+				c.KafkaTLSSkipVerify = true
+			},
+		},
+		{
+			name:   "ca file without tls",
+			modify: func(c *Config) { c.KafkaTLSCAFile = file },
+			want:   "требуют KAFKA_TLS_ENABLED=true",
+		},
+		{
+			name: "cert file without tls",
+			modify: func(c *Config) {
+				c.KafkaTLSCertFile = file
+				c.KafkaTLSKeyFile = file
+			},
+			want: "требуют KAFKA_TLS_ENABLED=true",
+		},
+		{
+			name: "cert without key",
+			modify: func(c *Config) {
+				c.KafkaTLSEnabled = true
+				c.KafkaTLSCertFile = file
+			},
+			want: "должны задаваться вместе",
+		},
+		{
+			name: "key without cert",
+			modify: func(c *Config) {
+				c.KafkaTLSEnabled = true
+				c.KafkaTLSKeyFile = file
+			},
+			want: "должны задаваться вместе",
+		},
+		{
+			name: "missing ca file",
+			modify: func(c *Config) {
+				c.KafkaTLSEnabled = true
+				c.KafkaTLSCAFile = missing
+			},
+			want: "KAFKA_TLS_CA_FILE: файл недоступен",
+		},
+		{
+			name: "missing key file",
+			modify: func(c *Config) {
+				c.KafkaTLSEnabled = true
+				c.KafkaTLSCertFile = file
+				c.KafkaTLSKeyFile = missing
+			},
+			want: "KAFKA_TLS_KEY_FILE: файл недоступен",
+		},
+		{
+			name: "sasl plain",
+			modify: func(c *Config) {
+				c.KafkaSASLMechanism = "PLAIN"
+				c.KafkaSASLUsername = "order-app"
+			},
+		},
+		{
+			name: "sasl scram-sha-256",
+			modify: func(c *Config) {
+				c.KafkaSASLMechanism = "SCRAM-SHA-256"
+				c.KafkaSASLUsername = "order-app"
+			},
+		},
+		{
+			name: "sasl scram-sha-512",
+			modify: func(c *Config) {
+				c.KafkaSASLMechanism = "SCRAM-SHA-512"
+				c.KafkaSASLUsername = "order-app"
+			},
+		},
+		{
+			name:   "sasl without username",
+			modify: func(c *Config) { c.KafkaSASLMechanism = "SCRAM-SHA-512" },
+			want:   "KAFKA_SASL_MECHANISM=SCRAM-SHA-512 требует KAFKA_SASL_USERNAME",
+		},
+		{
+			name: "unknown sasl mechanism",
+			modify: func(c *Config) {
+				c.KafkaSASLMechanism = "GSSAPI"
+				c.KafkaSASLUsername = "order-app"
+			},
+			want: `получено "GSSAPI"`,
+		},
+		{
+			name: "min bytes above max bytes",
+			modify: func(c *Config) {
+				c.KafkaMinBytes = 2048
+				c.KafkaMaxBytes = 1024
+			},
+			want: "KAFKA_MIN_BYTES",
+		},
+		{
+			name:   "zero max wait",
+			modify: func(c *Config) { c.KafkaMaxWait = 0 },
+			want:   "KAFKA_MAX_WAIT",
+		},
+		{
+			name:   "unknown start offset",
+			modify: func(c *Config) { c.KafkaStartOffset = "earliest" },
+			want:   "KAFKA_START_OFFSET",
+		},
+		{
+			name:   "zero breaker failures",
+			modify: func(c *Config) { c.KafkaBreakerFailures = 0 },
+			want:   "KAFKA_BREAKER_FAILURES",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig(t)
+			tt.modify(cfg)
+			err := cfg.Validate(ScopeKafka)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Validate = %v, ожидалась ошибка про %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigKafka(t *testing.T) {
+	t.Setenv("KAFKA_BROKERS", " kafka-1:9092, ,kafka-2:9092 ,")
+	t.Setenv("KAFKA_SASL_MECHANISM", "scram-sha-256")
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if want := []string{"kafka-1:9092", "kafka-2:9092"}; !slices.Equal(cfg.KafkaBrokers, want) {
+		t.Errorf("KafkaBrokers = %q, ожидалось %q", cfg.KafkaBrokers, want)
+	}
+	if cfg.KafkaSASLMechanism != "SCRAM-SHA-256" {
+		t.Errorf("KafkaSASLMechanism = %q, ожидалось SCRAM-SHA-256", cfg.KafkaSASLMechanism)
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    RateLimit
+		wantErr bool
+	}{
+		{spec: "5:10", want: RateLimit{Rate: 5, Burst: 10}},
+		{spec: " 0.5:1 ", want: RateLimit{Rate: 0.5, Burst: 1}},
+		{spec: "5", wantErr: true},
+		{spec: "0:10", wantErr: true},
+		{spec: "-1:10", wantErr: true},
+		{spec: "Inf:10", wantErr: true},
+		{spec: "NaN:10", wantErr: true},
+		{spec: "5:0", wantErr: true},
+		{spec: "5:1.5", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRateLimit(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRateLimit(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRateLimit(%q) = %+v, ожидалось %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParseRouteRateLimits(t *testing.T) {
+	got, err := ParseRouteRateLimits("GET  /order/:id=5:10; POST /orders/batch=1:2;")
+	if err != nil {
+		t.Fatalf("ParseRouteRateLimits: %v", err)
+	}
+	want := map[string]RateLimit{
+		"GET /order/:id":     {Rate: 5, Burst: 10},
+		"POST /orders/batch": {Rate: 1, Burst: 2},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("получено %v, ожидалось %v", got, want)
+	}
+	for route, l := range want {
+		if got[route] != l {
+			t.Errorf("%s: получено %+v, ожидалось %+v", route, got[route], l)
+		}
+	}
+
+	if got, err := ParseRouteRateLimits(""); err != nil || len(got) != 0 {
+		t.Errorf("пустая строка: %v, %v", got, err)
+	}
+	for _, spec := range []string{"/order/:id=5:10", "GET /order/:id", "GET /order/:id=5"} {
+		if _, err := ParseRouteRateLimits(spec); err == nil {
+			t.Errorf("ParseRouteRateLimits(%q) должен вернуть ошибку", spec)
+		}
+	}
+}
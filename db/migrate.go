@@ -1,32 +1,155 @@
 package db
 
 import (
+	"embed"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 
 	"order-app/config"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 )
 
+//go:embed migrations/*.sql
+var embeddedMigrations embed.FS
+
+type Migrator struct {
+	shards []*migrate.Migrate
+}
+
+type MigrationStatus struct {
+	Shard   int
+	Version uint
+	Dirty   bool
+	Applied bool
+}
+
 func RunMigrations(cfg *config.Config) error {
-	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName)
+	m, err := NewMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil {
+		return err
+	}
+
+	log.Printf("Миграции успешно выполнены. Количество шардов: %d", len(m.shards))
+	return nil
+}
+
+// NewMigrator применяет один и тот же набор миграций к основной базе и ко всем
+// шардам из DB_SHARD_DSNS.
+func NewMigrator(cfg *config.Config) (*Migrator, error) {
+	dsns := append([]string{config.BuildDSN(cfg)}, cfg.DBShardDSNs...)
+
+	m := &Migrator{}
+	for i, dsn := range dsns {
+		shard, err := newMigrate(cfg.MigrationsPath, dsn)
+		if err != nil {
+			m.Close()
+			return nil, fmt.Errorf("шард #%d: не удалось создать миграцию: %w", i, err)
+		}
+		m.shards = append(m.shards, shard)
+	}
+	return m, nil
+}
+
+func newMigrate(path, dsn string) (*migrate.Migrate, error) {
+	if path != "" {
+		return migrate.New("file://"+path, dsn)
+	}
 
-	m, err := migrate.New(
-		"file://"+cfg.MigrationsPath,
-		dsn,
-	)
+	src, err := iofs.New(embeddedMigrations, "migrations")
 	if err != nil {
-		return fmt.Errorf("не удалось создать миграцию: %w", err)
+		return nil, fmt.Errorf("не удалось открыть встроенные миграции: %w", err)
 	}
+	return migrate.NewWithSourceInstance("iofs", src, dsn)
+}
 
-	if err = m.Up(); err != nil && err != migrate.ErrNoChange {
-		return fmt.Errorf("не удалось выполнить миграции: %w", err)
+func (m *Migrator) Up() error {
+	return m.each("не удалось выполнить миграции", func(shard *migrate.Migrate) error {
+		return shard.Up()
+	})
+}
+
+func (m *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("количество шагов отката должно быть больше нуля, получено %d", steps)
+	}
+	return m.each("не удалось откатить миграции", func(shard *migrate.Migrate) error {
+		return shard.Steps(-steps)
+	})
+}
+
+func (m *Migrator) Goto(version uint) error {
+	return m.each("не удалось перейти на версию", func(shard *migrate.Migrate) error {
+		return shard.Migrate(version)
+	})
+}
+
+func (m *Migrator) Force(version int) error {
+	return m.each("не удалось принудительно установить версию", func(shard *migrate.Migrate) error {
+		return shard.Force(version)
+	})
+}
+
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	statuses := make([]MigrationStatus, 0, len(m.shards))
+	for i, shard := range m.shards {
+		version, dirty, err := shard.Version()
+		if errors.Is(err, migrate.ErrNilVersion) {
+			statuses = append(statuses, MigrationStatus{Shard: i})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("шард #%d: не удалось получить версию схемы: %w", i, err)
+		}
+		statuses = append(statuses, MigrationStatus{Shard: i, Version: version, Dirty: dirty, Applied: true})
 	}
+	return statuses, nil
+}
 
-	log.Println("Миграции успешно выполнены.")
+func (m *Migrator) Close() {
+	for _, shard := range m.shards {
+		shard.Close()
+	}
+}
+
+// each применяет fn к шардам по очереди и останавливается на первой ошибке, чтобы
+// не распространять сбойную миграцию дальше. В ошибку попадают версии, на которых
+// остались все шарды: после сбоя они могут различаться.
+func (m *Migrator) each(msg string, fn func(shard *migrate.Migrate) error) error {
+	for i, shard := range m.shards {
+		if err := fn(shard); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("шард #%d: %s: %w; состояние шардов: %s", i, msg, err, m.describeVersions())
+		}
+	}
 	return nil
 }
+
+func (m *Migrator) describeVersions() string {
+	statuses, err := m.Status()
+	if err != nil {
+		return err.Error()
+	}
+
+	parts := make([]string, 0, len(statuses))
+	for _, st := range statuses {
+		switch {
+		case !st.Applied:
+			parts = append(parts, fmt.Sprintf("#%d без миграций", st.Shard))
+		case st.Dirty:
+			parts = append(parts, fmt.Sprintf("#%d версия %d (dirty)", st.Shard, st.Version))
+		default:
+			parts = append(parts, fmt.Sprintf("#%d версия %d", st.Shard, st.Version))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
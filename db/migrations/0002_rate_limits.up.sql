@@ -0,0 +1,10 @@
+-- Счётчики RATE_LIMIT_BACKEND=postgres живут только в основной базе, но миграции
+-- применяются одним набором ко всем шардам: так версии схемы совпадают и
+-- migrate status/goto/down работают одинаково на каждом. На шардах таблица остаётся
+-- пустой, а UNLOGGED не пишет её в WAL и не тянет на реплики.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
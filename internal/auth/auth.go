@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/MicahParks/keyfunc/v3"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	ScopeOrdersRead  = "orders:read"
+	ScopeOrdersAdmin = "orders:admin"
+
+	// RoleSupport получает заказы с замаскированными персональными данными.
+	RoleSupport = "support"
+
+	APIKeyHeader = "X-API-Key"
+)
+
+var (
+	ErrUnauthenticated = errors.New("учётные данные не переданы")
+	ErrInvalidAPIKey   = errors.New("неизвестный API-ключ")
+	ErrInvalidToken    = errors.New("недействительный токен")
+)
+
+type Principal struct {
+	Subject string
+	Method  string
+	Role    string
+	Scopes  []string
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope) || slices.Contains(p.Scopes, ScopeOrdersAdmin)
+}
+
+type apiKey struct {
+	Name   string   `json:"name"`
+	Hash   string   `json:"hash"`
+	Role   string   `json:"role"`
+	Scopes []string `json:"scopes"`
+
+	digest []byte
+}
+
+type Authenticator struct {
+	apiKeys  []apiKey
+	keyfunc  jwt.Keyfunc
+	issuer   string
+	audience string
+}
+
+type Options struct {
+	APIKeysFile string
+	JWKSFile    string
+	JWTIssuer   string
+	JWTAudience string
+}
+
+func NewAuthenticator(opts Options) (*Authenticator, error) {
+	a := &Authenticator{issuer: opts.JWTIssuer, audience: opts.JWTAudience}
+
+	if opts.APIKeysFile != "" {
+		keys, err := loadAPIKeys(opts.APIKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		a.apiKeys = keys
+	}
+
+	if opts.JWKSFile != "" {
+		raw, err := os.ReadFile(opts.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать JWKS: %w", err)
+		}
+		kf, err := keyfunc.NewJWKSetJSON(raw)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось разобрать JWKS: %w", err)
+		}
+		a.keyfunc = kf.Keyfunc
+	}
+
+	if len(a.apiKeys) == 0 && a.keyfunc == nil {
+		return nil, errors.New("не настроен ни один способ аутентификации: укажите AUTH_API_KEYS_FILE или AUTH_JWKS_FILE")
+	}
+	return a, nil
+}
+
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return a.authenticateAPIKey(key)
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") && a.keyfunc != nil {
+		return a.authenticateJWT(strings.TrimSpace(token))
+	}
+	return nil, ErrUnauthenticated
+}
+
+// Credential возвращает учётные данные запроса как есть — API-ключ или bearer-токен —
+// без проверки; пустая строка, если их нет.
+func Credential(r *http.Request) string {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+func (a *Authenticator) authenticateAPIKey(key string) (*Principal, error) {
+	digest := sha256.Sum256([]byte(key))
+
+	var found *apiKey
+	for i := range a.apiKeys {
+		if subtle.ConstantTimeCompare(digest[:], a.apiKeys[i].digest) == 1 {
+			found = &a.apiKeys[i]
+		}
+	}
+	if found == nil {
+		return nil, ErrInvalidAPIKey
+	}
+	return &Principal{Subject: found.Name, Method: "api_key", Role: found.Role, Scopes: found.Scopes}, nil
+}
+
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Scope string   `json:"scope"`
+	Scp   []string `json:"scp"`
+	Role  string   `json:"role"`
+}
+
+func (a *Authenticator) authenticateJWT(raw string) (*Principal, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "PS256", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+	}
+	if a.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(a.issuer))
+	}
+	if a.audience != "" {
+		opts = append(opts, jwt.WithAudience(a.audience))
+	}
+
+	var claims tokenClaims
+	if _, err := jwt.ParseWithClaims(raw, &claims, a.keyfunc, opts...); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	scopes := append(strings.Fields(claims.Scope), claims.Scp...)
+	return &Principal{Subject: claims.Subject, Method: "jwt", Role: claims.Role, Scopes: scopes}, nil
+}
+
+func loadAPIKeys(path string) ([]apiKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать файл API-ключей: %w", err)
+	}
+
+	var keys []apiKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("не удалось разобрать файл API-ключей: %w", err)
+	}
+
+	for i := range keys {
+		encoded, ok := strings.CutPrefix(keys[i].Hash, "sha256:")
+		if !ok {
+			return nil, fmt.Errorf("API-ключ %s: ожидался хэш в формате sha256:<hex>", keys[i].Name)
+		}
+		digest, err := hex.DecodeString(encoded)
+		if err != nil || len(digest) != sha256.Size {
+			return nil, fmt.Errorf("API-ключ %s: некорректный sha256-хэш", keys[i].Name)
+		}
+		keys[i].digest = digest
+	}
+	return keys, nil
+}
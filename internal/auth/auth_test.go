@@ -0,0 +1,241 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "https://issuer.example"
+	testAudience = "order-app"
+	testKID      = "test-key"
+
+	readKey  = "read-key-0123456789"
+	adminKey = "admin-key-0123456789"
+)
+
+// testEnv — аутентификатор с файлом API-ключей и локальным JWKS из одного RSA-ключа.
+type testEnv struct {
+	auth *Authenticator
+	key  *rsa.PrivateKey
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	dir := t.TempDir()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "RSA", "kid": testKID, "use": "sig", "alg": "RS256",
+		"n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	jwksFile := filepath.Join(dir, "jwks.json")
+	writeFile(t, jwksFile, jwks)
+
+	hash := func(k string) string {
+		d := sha256.Sum256([]byte(k))
+		return "sha256:" + hex.EncodeToString(d[:])
+	}
+	keys, _ := json.Marshal([]map[string]any{
+		{"name": "support-bot", "hash": hash(readKey), "role": RoleSupport, "scopes": []string{ScopeOrdersRead}},
+		{"name": "ops", "hash": hash(adminKey), "scopes": []string{ScopeOrdersAdmin}},
+	})
+	keysFile := filepath.Join(dir, "keys.json")
+	writeFile(t, keysFile, keys)
+
+	a, err := NewAuthenticator(Options{APIKeysFile: keysFile, JWKSFile: jwksFile, JWTIssuer: testIssuer, JWTAudience: testAudience})
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+	return &testEnv{auth: a, key: key}
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   "user-1",
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": ScopeOrdersRead,
+		"role":  RoleSupport,
+	}
+}
+
+func (e *testEnv) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = testKID
+	s, err := tok.SignedString(e.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func request(header, value string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/order/uid", nil)
+	if header != "" {
+		r.Header.Set(header, value)
+	}
+	return r
+}
+
+func TestAuthenticate(t *testing.T) {
+	env := newTestEnv(t)
+
+	without := func(key string) jwt.MapClaims {
+		c := validClaims()
+		delete(c, key)
+		return c
+	}
+	with := func(key string, val any) jwt.MapClaims {
+		c := validClaims()
+		c[key] = val
+		return c
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign := jwt.NewWithClaims(jwt.SigningMethodRS256, validClaims())
+	foreign.Header["kid"] = testKID
+	foreignToken, _ := foreign.SignedString(otherKey)
+
+	unknownKID := jwt.NewWithClaims(jwt.SigningMethodRS256, validClaims())
+	unknownKID.Header["kid"] = "missing"
+	unknownKIDToken, _ := unknownKID.SignedString(env.key)
+
+	noneToken, _ := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+	// Подмена алгоритма: HS256, подписанный открытым ключом из JWKS как секретом.
+	hs := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims())
+	hs.Header["kid"] = testKID
+	hsToken, _ := hs.SignedString(env.key.PublicKey.N.Bytes())
+
+	tests := []struct {
+		name    string
+		req     *http.Request
+		want    *Principal
+		wantErr error
+	}{
+		{
+			name: "api key",
+			req:  request(APIKeyHeader, readKey),
+			want: &Principal{Subject: "support-bot", Method: "api_key", Role: RoleSupport, Scopes: []string{ScopeOrdersRead}},
+		},
+		{name: "unknown api key", req: request(APIKeyHeader, readKey+"x"), wantErr: ErrInvalidAPIKey},
+		{name: "no credentials", req: request("", ""), wantErr: ErrUnauthenticated},
+		{name: "basic scheme", req: request("Authorization", "Basic dXNlcjpwYXNz"), wantErr: ErrUnauthenticated},
+		{
+			name: "jwt",
+			req:  request("Authorization", "Bearer "+env.sign(t, validClaims())),
+			want: &Principal{Subject: "user-1", Method: "jwt", Role: RoleSupport, Scopes: []string{ScopeOrdersRead}},
+		},
+		{
+			name: "jwt scp claim",
+			req:  request("Authorization", "bearer "+env.sign(t, with("scp", []string{ScopeOrdersAdmin}))),
+			want: &Principal{Subject: "user-1", Method: "jwt", Role: RoleSupport, Scopes: []string{ScopeOrdersRead, ScopeOrdersAdmin}},
+		},
+		{name: "missing exp", req: request("Authorization", "Bearer "+env.sign(t, without("exp"))), wantErr: ErrInvalidToken},
+		{name: "expired", req: request("Authorization", "Bearer "+env.sign(t, with("exp", time.Now().Add(-time.Minute).Unix()))), wantErr: ErrInvalidToken},
+		{name: "wrong issuer", req: request("Authorization", "Bearer "+env.sign(t, with("iss", "https://evil.example"))), wantErr: ErrInvalidToken},
+		{name: "wrong audience", req: request("Authorization", "Bearer "+env.sign(t, with("aud", "other-app"))), wantErr: ErrInvalidToken},
+		{name: "foreign signature", req: request("Authorization", "Bearer "+foreignToken), wantErr: ErrInvalidToken},
+		{name: "unknown kid", req: request("Authorization", "Bearer "+unknownKIDToken), wantErr: ErrInvalidToken},
+		{name: "alg none", req: request("Authorization", "Bearer "+noneToken), wantErr: ErrInvalidToken},
+		{name: "hs256 downgrade", req: request("Authorization", "Bearer "+hsToken), wantErr: ErrInvalidToken},
+		{name: "garbage token", req: request("Authorization", "Bearer not.a.jwt"), wantErr: ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := env.auth.Authenticate(tt.req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) || got != nil {
+					t.Fatalf("Authenticate = %+v, %v; ожидалась ошибка %v", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if got.Subject != tt.want.Subject || got.Method != tt.want.Method || got.Role != tt.want.Role ||
+				len(got.Scopes) != len(tt.want.Scopes) {
+				t.Fatalf("Authenticate = %+v, ожидалось %+v", got, tt.want)
+			}
+			for i := range got.Scopes {
+				if got.Scopes[i] != tt.want.Scopes[i] {
+					t.Fatalf("Scopes = %v, ожидалось %v", got.Scopes, tt.want.Scopes)
+				}
+			}
+		})
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		scopes []string
+		scope  string
+		want   bool
+	}{
+		{scopes: []string{ScopeOrdersRead}, scope: ScopeOrdersRead, want: true},
+		{scopes: []string{ScopeOrdersRead}, scope: ScopeOrdersAdmin, want: false},
+		{scopes: []string{ScopeOrdersAdmin}, scope: ScopeOrdersRead, want: true},
+		{scopes: []string{ScopeOrdersAdmin}, scope: ScopeOrdersAdmin, want: true},
+		{scopes: nil, scope: ScopeOrdersRead, want: false},
+		{scopes: []string{"orders:write"}, scope: ScopeOrdersRead, want: false},
+	}
+	for _, tt := range tests {
+		p := &Principal{Scopes: tt.scopes}
+		if got := p.HasScope(tt.scope); got != tt.want {
+			t.Errorf("%v.HasScope(%s) = %v, ожидалось %v", tt.scopes, tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestNewAuthenticatorErrors(t *testing.T) {
+	dir := t.TempDir()
+	badHash := filepath.Join(dir, "bad-hash.json")
+	writeFile(t, badHash, []byte(`[{"name":"k","hash":"md5:abcd"}]`))
+	shortHash := filepath.Join(dir, "short-hash.json")
+	writeFile(t, shortHash, []byte(`[{"name":"k","hash":"sha256:abcd"}]`))
+	badJWKS := filepath.Join(dir, "bad-jwks.json")
+	writeFile(t, badJWKS, []byte(`{"keys":`))
+
+	for name, opts := range map[string]Options{
+		"nothing configured":  {},
+		"missing keys file":   {APIKeysFile: filepath.Join(dir, "missing.json")},
+		"hash without sha256": {APIKeysFile: badHash},
+		"short hash":          {APIKeysFile: shortHash},
+		"broken jwks":         {JWKSFile: badJWKS},
+	} {
+		if _, err := NewAuthenticator(opts); err == nil {
+			t.Errorf("%s: ожидалась ошибка", name)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newRouter(a *Authenticator) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	ok := func(c *gin.Context) {
+		subject := ""
+		if p := FromContext(c); p != nil {
+			subject = p.Subject
+		}
+		c.String(http.StatusOK, subject)
+	}
+	r.GET("/read", a.Identify(), a.Require(ScopeOrdersRead), ok)
+	r.GET("/admin", a.Identify(), a.Require(ScopeOrdersAdmin), ok)
+	r.GET("/require-only", a.Require(ScopeOrdersRead), ok)
+	return r
+}
+
+func TestMiddleware(t *testing.T) {
+	env := newTestEnv(t)
+	r := newRouter(env.auth)
+	readToken := env.sign(t, validClaims())
+
+	tests := []struct {
+		name        string
+		path        string
+		header      string
+		value       string
+		wantStatus  int
+		wantSubject string
+	}{
+		{name: "no credentials", path: "/read", wantStatus: http.StatusUnauthorized},
+		{name: "bad api key", path: "/read", header: APIKeyHeader, value: "nope", wantStatus: http.StatusUnauthorized},
+		{name: "bad token", path: "/read", header: "Authorization", value: "Bearer nope", wantStatus: http.StatusUnauthorized},
+		{name: "read key on read", path: "/read", header: APIKeyHeader, value: readKey, wantStatus: http.StatusOK, wantSubject: "support-bot"},
+		{name: "read key on admin", path: "/admin", header: APIKeyHeader, value: readKey, wantStatus: http.StatusForbidden},
+		{name: "admin key on read", path: "/read", header: APIKeyHeader, value: adminKey, wantStatus: http.StatusOK, wantSubject: "ops"},
+		{name: "admin key on admin", path: "/admin", header: APIKeyHeader, value: adminKey, wantStatus: http.StatusOK, wantSubject: "ops"},
+		{name: "jwt on read", path: "/read", header: "Authorization", value: "Bearer " + readToken, wantStatus: http.StatusOK, wantSubject: "user-1"},
+		{name: "jwt on admin", path: "/admin", header: "Authorization", value: "Bearer " + readToken, wantStatus: http.StatusForbidden},
+		{name: "require without identify", path: "/require-only", header: APIKeyHeader, value: readKey, wantStatus: http.StatusOK, wantSubject: "support-bot"},
+		{name: "require without identify rejects", path: "/require-only", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("статус %d, ожидался %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			switch tt.wantStatus {
+			case http.StatusUnauthorized:
+				if !strings.HasPrefix(w.Header().Get("WWW-Authenticate"), "Bearer") {
+					t.Errorf("нет заголовка WWW-Authenticate")
+				}
+			case http.StatusForbidden:
+				if !strings.Contains(w.Body.String(), `"required_scope":"`+ScopeOrdersAdmin+`"`) {
+					t.Errorf("в ответе нет required_scope: %s", w.Body)
+				}
+			case http.StatusOK:
+				if w.Body.String() != tt.wantSubject {
+					t.Errorf("субъект %q, ожидался %q", w.Body, tt.wantSubject)
+				}
+			}
+		})
+	}
+}
+
+// После неудачного Identify токен не должен проверяться в Require повторно.
+func TestRequireTrustsIdentify(t *testing.T) {
+	env := newTestEnv(t)
+	calls := 0
+	keyfunc := env.auth.keyfunc
+	env.auth.keyfunc = func(tok *jwt.Token) (any, error) {
+		calls++
+		return keyfunc(tok)
+	}
+	r := newRouter(env.auth)
+
+	expired := validClaims()
+	expired["exp"] = 1
+	req := httptest.NewRequest(http.MethodGet, "/read", nil)
+	req.Header.Set("Authorization", "Bearer "+env.sign(t, expired))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("статус %d, ожидался 401", w.Code)
+	}
+	if calls != 1 {
+		t.Fatalf("токен проверен %d раз, ожидался 1", calls)
+	}
+}
+
+func TestNilAuthenticatorPassesThrough(t *testing.T) {
+	r := newRouter(nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("статус %d, ожидался 200", w.Code)
+	}
+}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"order-app/internal/domain"
@@ -11,6 +12,17 @@ type OrderCache struct {
 	data map[string]*cacheEntry
 	mu   sync.RWMutex
 	ttl  time.Duration
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+type Stats struct {
+	Size      int
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
 }
 
 type cacheEntry struct {
@@ -41,17 +53,28 @@ func (c *OrderCache) Get(orderUID string) (*domain.Order, bool) {
 	defer c.mu.RUnlock()
 
 	entry, exists := c.data[orderUID]
-	if !exists {
-		return nil, false
-	}
-
-	if time.Since(entry.timestamp) > c.ttl {
+	if !exists || time.Since(entry.timestamp) > c.ttl {
+		c.misses.Add(1)
 		return nil, false
 	}
 
+	c.hits.Add(1)
 	return entry.order, true
 }
 
+func (c *OrderCache) Stats() Stats {
+	c.mu.RLock()
+	size := len(c.data)
+	c.mu.RUnlock()
+
+	return Stats{
+		Size:      size,
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
+
 func (c *OrderCache) startCleaner() {
 	ticker := time.NewTicker(time.Hour)
 	for range ticker.C {
@@ -66,6 +89,7 @@ func (c *OrderCache) cleanUp() {
 	for uid, entry := range c.data {
 		if now.Sub(entry.timestamp) > c.ttl {
 			delete(c.data, uid)
+			c.evictions.Add(1)
 		}
 	}
 }
@@ -0,0 +1,122 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"order-app/internal/domain"
+)
+
+type Format string
+
+const (
+	FormatNDJSON Format = "ndjson"
+	FormatCSV    Format = "csv"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatNDJSON, FormatCSV:
+		return f, nil
+	default:
+		return "", fmt.Errorf("неизвестный формат выгрузки %q: ожидалось ndjson или csv", s)
+	}
+}
+
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// Writer пишет заказы в выбранном формате. Close дописывает буферизованные
+// данные, но не закрывает нижележащий io.Writer.
+type Writer interface {
+	Write(order *domain.Order) error
+	Close() error
+}
+
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatNDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w)}, nil
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: cw}, nil
+	default:
+		return nil, fmt.Errorf("неизвестный формат выгрузки %q", format)
+	}
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(order *domain.Order) error {
+	return w.enc.Encode(order)
+}
+
+func (w *ndjsonWriter) Close() error {
+	return nil
+}
+
+var csvHeader = []string{
+	"order_uid", "track_number", "entry", "locale", "internal_signature", "customer_id",
+	"delivery_service", "shardkey", "sm_id", "date_created", "oof_shard",
+	"delivery_name", "delivery_phone", "delivery_zip", "delivery_city", "delivery_address",
+	"delivery_region", "delivery_email",
+	"payment_transaction", "payment_request_id", "payment_currency", "payment_provider",
+	"payment_amount", "payment_dt", "payment_bank", "payment_delivery_cost",
+	"payment_goods_total", "payment_custom_fee",
+	"item_chrt_id", "item_track_number", "item_price", "item_rid", "item_name", "item_sale",
+	"item_size", "item_total_price", "item_nm_id", "item_brand", "item_status",
+}
+
+// csvWriter пишет по строке на каждый товар заказа, повторяя в ней поля заказа,
+// доставки и оплаты. Заказ без товаров даёт одну строку с пустыми колонками item_*.
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (w *csvWriter) Write(o *domain.Order) error {
+	base := []string{
+		o.OrderUID, strconv.Itoa(o.TrackNumber), o.Entry, o.Locale, o.InternalSignature, o.CustomerID,
+		o.DeliveryService, o.Shardkey, strconv.Itoa(o.SmID), o.DateCreated.Format(time.RFC3339), o.OofShard,
+		o.Delivery.Name, o.Delivery.Phone, o.Delivery.Zip, o.Delivery.City, o.Delivery.Address,
+		o.Delivery.Region, o.Delivery.Email,
+		o.Payment.Transaction, o.Payment.RequestID, o.Payment.Currency, o.Payment.Provider,
+		strconv.Itoa(o.Payment.Amount), strconv.FormatInt(o.Payment.PaymentDt, 10), o.Payment.Bank,
+		strconv.Itoa(o.Payment.DeliveryCost), strconv.Itoa(o.Payment.GoodsTotal), strconv.Itoa(o.Payment.CustomFee),
+	}
+
+	if len(o.Items) == 0 {
+		return w.w.Write(append(base, make([]string, len(csvHeader)-len(base))...))
+	}
+	for _, it := range o.Items {
+		row := append(base[:len(base):len(base)],
+			strconv.Itoa(it.ChrtID), it.TrackNumber, strconv.Itoa(it.Price), it.Rid, it.Name, strconv.Itoa(it.Sale),
+			it.Size, strconv.Itoa(it.TotalPrice), strconv.Itoa(it.NmID), it.Brand, strconv.Itoa(it.Status),
+		)
+		if err := w.w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *csvWriter) Close() error {
+	w.w.Flush()
+	return w.w.Error()
+}
@@ -0,0 +1,117 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"order-app/internal/export"
+	"order-app/internal/repository/storetest"
+)
+
+func TestCSVQuoting(t *testing.T) {
+	order := storetest.NewOrder("uid,with-comma")
+	order.Delivery.Name = `Ivan "Vanya" Petrov`
+	order.Delivery.Address = "Lenina 1,\nкв. 2"
+	order.Delivery.City = " Moscow "
+	order.Items[0].Name = `Тушь "Объём"`
+	order.Items = append(order.Items, order.Items[0])
+	order.Items[1].Brand = "Brand\r\nLine"
+
+	var buf bytes.Buffer
+	w, err := export.NewWriter(export.FormatCSV, &buf)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	if err := w.Write(order); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	raw := buf.String()
+	for _, want := range []string{
+		`"uid,with-comma"`,
+		`"Ivan ""Vanya"" Petrov"`,
+		"\"Lenina 1,\nкв. 2\"",
+		`"Тушь ""Объём"""`,
+		" Moscow ",
+	} {
+		if !strings.Contains(raw, want) {
+			t.Errorf("в выгрузке нет %q:\n%s", want, raw)
+		}
+	}
+
+	records, err := csv.NewReader(strings.NewReader(raw)).ReadAll()
+	if err != nil {
+		t.Fatalf("выгрузка не читается как CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("строк %d, ожидались заголовок и по строке на товар", len(records))
+	}
+	header := records[0]
+	for i, row := range records[1:] {
+		if len(row) != len(header) {
+			t.Fatalf("строка %d: %d колонок, в заголовке %d", i+1, len(row), len(header))
+		}
+		got := make(map[string]string, len(row))
+		for j, name := range header {
+			got[name] = row[j]
+		}
+		for name, want := range map[string]string{
+			"order_uid":        order.OrderUID,
+			"delivery_name":    order.Delivery.Name,
+			"delivery_address": order.Delivery.Address,
+			"delivery_city":    order.Delivery.City,
+			"item_name":        order.Items[i].Name,
+		} {
+			if got[name] != want {
+				t.Errorf("строка %d, %s: %q, ожидалось %q", i+1, name, got[name], want)
+			}
+		}
+	}
+	// encoding/csv при чтении сводит \r\n внутри кавычек к \n.
+	if got := records[2][len(header)-2]; got != "Brand\nLine" {
+		t.Errorf("item_brand второго товара: %q", got)
+	}
+}
+
+func TestCSVOrderWithoutItems(t *testing.T) {
+	order := storetest.NewOrder("uid-no-items")
+	order.Items = nil
+
+	var buf bytes.Buffer
+	w, _ := export.NewWriter(export.FormatCSV, &buf)
+	if err := w.Write(order); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if len(records) != 2 || len(records[1]) != len(records[0]) {
+		t.Fatalf("ожидалась одна строка полной ширины: %v", records)
+	}
+	if records[1][0] != order.OrderUID || records[1][len(records[1])-1] != "" {
+		t.Errorf("строка без товаров: %v", records[1])
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, s := range []string{"ndjson", "csv"} {
+		if f, err := export.ParseFormat(s); err != nil || string(f) != s {
+			t.Errorf("ParseFormat(%q) = %q, %v", s, f, err)
+		}
+	}
+	for _, s := range []string{"", "CSV", "json"} {
+		if _, err := export.ParseFormat(s); err == nil {
+			t.Errorf("ParseFormat(%q) принял неизвестный формат", s)
+		}
+	}
+}
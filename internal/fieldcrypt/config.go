@@ -0,0 +1,16 @@
+package fieldcrypt
+
+import (
+	"order-app/config"
+)
+
+func FromConfig(cfg *config.Config) (*Keyring, error) {
+	switch {
+	case cfg.PIIKeysFile != "":
+		return LoadKeyringFile(cfg.PIIKeysFile)
+	case cfg.PIIKeys != "":
+		return ParseKeyring(cfg.PIIPrimaryKey, cfg.PIIKeys)
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,119 @@
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+)
+
+type Envelope struct {
+	KeyID      string `json:"kid"`
+	WrappedKey []byte `json:"wrapped_key"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Seal шифрует plaintext случайным ключом данных, а сам ключ данных — основным ключом связки.
+// aad связывает шифротекст с записью, чтобы его нельзя было подставить в другой заказ.
+func (k *Keyring) Seal(plaintext, aad []byte) (*Envelope, error) {
+	dek := make([]byte, keySize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, fmt.Errorf("не удалось сгенерировать ключ данных: %w", err)
+	}
+
+	ciphertext, err := seal(dek, plaintext, aad)
+	if err != nil {
+		return nil, err
+	}
+
+	env := &Envelope{Ciphertext: ciphertext}
+	if err := k.wrap(env, dek); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+func (k *Keyring) Open(env *Envelope, aad []byte) ([]byte, error) {
+	dek, err := k.unwrap(env)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := open(dek, env.Ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось расшифровать данные: %w", err)
+	}
+	return plaintext, nil
+}
+
+// Rewrap перешифровывает ключ данных основным ключом, не трогая сами данные.
+func (k *Keyring) Rewrap(env *Envelope) (bool, error) {
+	if env.KeyID == k.primary {
+		return false, nil
+	}
+	dek, err := k.unwrap(env)
+	if err != nil {
+		return false, err
+	}
+	if err := k.wrap(env, dek); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (k *Keyring) wrap(env *Envelope, dek []byte) error {
+	kek, err := k.key(k.primary)
+	if err != nil {
+		return err
+	}
+	wrapped, err := seal(kek, dek, []byte(k.primary))
+	if err != nil {
+		return fmt.Errorf("не удалось зашифровать ключ данных: %w", err)
+	}
+	env.KeyID = k.primary
+	env.WrappedKey = wrapped
+	return nil
+}
+
+func (k *Keyring) unwrap(env *Envelope) ([]byte, error) {
+	kek, err := k.key(env.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	dek, err := open(kek, env.WrappedKey, []byte(env.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось расшифровать ключ данных %s: %w", env.KeyID, err)
+	}
+	return dek, nil
+}
+
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("не удалось сгенерировать nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(key, data, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("шифротекст короче nonce")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,147 @@
+package fieldcrypt_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"order-app/internal/fieldcrypt"
+)
+
+// Фиксированные тестовые KEK: результаты детерминированы по ключам, а nonce и
+// ключи данных по-прежнему случайные.
+var (
+	kek1 = bytes.Repeat([]byte{0x11}, 32)
+	kek2 = bytes.Repeat([]byte{0x22}, 32)
+)
+
+func mustKeyring(t *testing.T, primary string, keys map[string][]byte) *fieldcrypt.Keyring {
+	t.Helper()
+	k, err := fieldcrypt.NewKeyring(primary, keys)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return k
+}
+
+func TestSealOpen(t *testing.T) {
+	ring := mustKeyring(t, "k1", map[string][]byte{"k1": kek1, "k2": kek2})
+	plaintext := []byte(`{"name":"Test Testov","phone":"+9720000000"}`)
+
+	tests := []struct {
+		name    string
+		tamper  func(env *fieldcrypt.Envelope)
+		aad     []byte
+		wantErr error
+	}{
+		{name: "round trip", aad: []byte("uid-1")},
+		{name: "wrong order_uid", aad: []byte("uid-2"), wantErr: errAny},
+		{name: "empty aad", aad: nil, wantErr: errAny},
+		{name: "kid swapped to other known key", aad: []byte("uid-1"), tamper: func(env *fieldcrypt.Envelope) { env.KeyID = "k2" }, wantErr: errAny},
+		{name: "unknown kid", aad: []byte("uid-1"), tamper: func(env *fieldcrypt.Envelope) { env.KeyID = "k9" }, wantErr: fieldcrypt.ErrUnknownKey},
+		{name: "ciphertext modified", aad: []byte("uid-1"), tamper: func(env *fieldcrypt.Envelope) { env.Ciphertext[len(env.Ciphertext)-1] ^= 1 }, wantErr: errAny},
+		{name: "wrapped key modified", aad: []byte("uid-1"), tamper: func(env *fieldcrypt.Envelope) { env.WrappedKey[0] ^= 1 }, wantErr: errAny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := ring.Seal(plaintext, []byte("uid-1"))
+			if err != nil {
+				t.Fatalf("Seal: %v", err)
+			}
+			if env.KeyID != "k1" {
+				t.Fatalf("конверт обёрнут ключом %q, ожидался основной k1", env.KeyID)
+			}
+			if bytes.Contains(env.Ciphertext, plaintext) {
+				t.Fatal("шифротекст содержит открытый текст")
+			}
+			if tt.tamper != nil {
+				tt.tamper(env)
+			}
+
+			got, err := ring.Open(env, tt.aad)
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("Open: %v", err)
+			case tt.wantErr == nil && !bytes.Equal(got, plaintext):
+				t.Fatalf("Open вернул %q, ожидалось %q", got, plaintext)
+			case tt.wantErr == errAny && err == nil:
+				t.Fatal("Open должен был вернуть ошибку")
+			case tt.wantErr != nil && tt.wantErr != errAny && !errors.Is(err, tt.wantErr):
+				t.Fatalf("Open вернул %v, ожидалось %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+var errAny = errors.New("любая ошибка")
+
+func TestRewrapAfterRotation(t *testing.T) {
+	before := mustKeyring(t, "k1", map[string][]byte{"k1": kek1})
+	after := mustKeyring(t, "k2", map[string][]byte{"k1": kek1, "k2": kek2})
+	onlyNew := mustKeyring(t, "k2", map[string][]byte{"k2": kek2})
+	aad := []byte("uid-rotate")
+	plaintext := []byte("secret")
+
+	env, err := before.Seal(plaintext, aad)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	ciphertext := append([]byte(nil), env.Ciphertext...)
+
+	if _, err := onlyNew.Open(env, aad); !errors.Is(err, fieldcrypt.ErrUnknownKey) {
+		t.Fatalf("до перешифрования конверт не должен открываться без k1, получено %v", err)
+	}
+
+	changed, err := after.Rewrap(env)
+	if err != nil || !changed {
+		t.Fatalf("Rewrap = %v, %v; ожидалось true, nil", changed, err)
+	}
+	if env.KeyID != "k2" {
+		t.Fatalf("после Rewrap kid = %q, ожидался k2", env.KeyID)
+	}
+	if !bytes.Equal(env.Ciphertext, ciphertext) {
+		t.Fatal("Rewrap не должен менять шифротекст данных")
+	}
+
+	for name, ring := range map[string]*fieldcrypt.Keyring{"after": after, "only new key": onlyNew} {
+		got, err := ring.Open(env, aad)
+		if err != nil || !bytes.Equal(got, plaintext) {
+			t.Fatalf("%s: Open = %q, %v", name, got, err)
+		}
+	}
+
+	changed, err = after.Rewrap(env)
+	if err != nil || changed {
+		t.Fatalf("повторный Rewrap = %v, %v; ожидалось false, nil", changed, err)
+	}
+}
+
+func TestParseKeyring(t *testing.T) {
+	b64 := base64.StdEncoding.EncodeToString
+	tests := []struct {
+		name    string
+		primary string
+		spec    string
+		wantErr bool
+	}{
+		{name: "two keys", primary: "k2", spec: "k1:" + b64(kek1) + ", k2:" + b64(kek2)},
+		{name: "primary missing", primary: "k3", spec: "k1:" + b64(kek1), wantErr: true},
+		{name: "short key", primary: "k1", spec: "k1:" + b64(kek1[:16]), wantErr: true},
+		{name: "bad base64", primary: "k1", spec: "k1:***", wantErr: true},
+		{name: "no id", primary: "k1", spec: b64(kek1), wantErr: true},
+		{name: "empty", primary: "k1", spec: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring, err := fieldcrypt.ParseKeyring(tt.primary, tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKeyring: err = %v, wantErr = %v", err, tt.wantErr)
+			}
+			if err == nil && ring.PrimaryID() != tt.primary {
+				t.Fatalf("PrimaryID = %q, ожидался %q", ring.PrimaryID(), tt.primary)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package fieldcrypt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const keySize = 32
+
+var ErrUnknownKey = errors.New("неизвестный ключ шифрования")
+
+type Keyring struct {
+	primary string
+	keys    map[string][]byte
+}
+
+type keyFile struct {
+	Primary string            `json:"primary"`
+	Keys    map[string]string `json:"keys"`
+}
+
+func NewKeyring(primary string, keys map[string][]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("не задано ни одного ключа шифрования")
+	}
+	for id, key := range keys {
+		if id == "" || strings.ContainsAny(id, ":,") {
+			return nil, fmt.Errorf("некорректный идентификатор ключа %q", id)
+		}
+		if len(key) != keySize {
+			return nil, fmt.Errorf("ключ %s: ожидалось %d байт, получено %d", id, keySize, len(key))
+		}
+	}
+	if _, ok := keys[primary]; !ok {
+		return nil, fmt.Errorf("основной ключ %q: %w", primary, ErrUnknownKey)
+	}
+	return &Keyring{primary: primary, keys: keys}, nil
+}
+
+func LoadKeyringFile(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать файл ключей: %w", err)
+	}
+
+	var f keyFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("не удалось разобрать файл ключей: %w", err)
+	}
+
+	keys := make(map[string][]byte, len(f.Keys))
+	for id, encoded := range f.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("ключ %s: некорректный base64: %w", id, err)
+		}
+		keys[id] = key
+	}
+	return NewKeyring(f.Primary, keys)
+}
+
+// ParseKeyring разбирает ключи из строки вида "k1:base64,k2:base64".
+func ParseKeyring(primary, spec string) (*Keyring, error) {
+	keys := make(map[string][]byte)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("ожидался формат id:base64, получено %q", part)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("ключ %s: некорректный base64: %w", id, err)
+		}
+		keys[id] = key
+	}
+	return NewKeyring(primary, keys)
+}
+
+func (k *Keyring) PrimaryID() string {
+	return k.primary
+}
+
+func (k *Keyring) key(id string) ([]byte, error) {
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("ключ %q: %w", id, ErrUnknownKey)
+	}
+	return key, nil
+}
@@ -0,0 +1,80 @@
+package grpcapi
+
+import (
+	"context"
+	"net/http"
+
+	"order-app/internal/auth"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type principalKey struct{}
+
+// publicMethods не требуют аутентификации, чтобы балансировщики могли проверять здоровье.
+var publicMethods = map[string]bool{
+	"/grpc.health.v1.Health/Check": true,
+	"/grpc.health.v1.Health/Watch": true,
+}
+
+// authenticate принимает те же учётные данные, что и HTTP API: метаданные
+// x-api-key или authorization: Bearer <JWT>. Все методы OrderService требуют orders:read.
+func authenticate(ctx context.Context, authn *auth.Authenticator, method string) (context.Context, error) {
+	if authn == nil || publicMethods[method] {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	req := &http.Request{Header: http.Header{}}
+	for _, key := range []string{auth.APIKeyHeader, "Authorization"} {
+		if vals := md.Get(key); len(vals) > 0 {
+			req.Header.Set(key, vals[0])
+		}
+	}
+
+	p, err := authn.Authenticate(req)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "Unauthorized")
+	}
+	if !p.HasScope(auth.ScopeOrdersRead) {
+		return nil, status.Errorf(codes.PermissionDenied, "требуется scope %s", auth.ScopeOrdersRead)
+	}
+	return context.WithValue(ctx, principalKey{}, p), nil
+}
+
+func principalFrom(ctx context.Context) *auth.Principal {
+	p, _ := ctx.Value(principalKey{}).(*auth.Principal)
+	return p
+}
+
+func unaryAuth(authn *auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, authn, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
+
+func streamAuth(authn *auth.Authenticator) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), authn, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+	}
+}
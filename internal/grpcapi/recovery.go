@@ -0,0 +1,40 @@
+package grpcapi
+
+import (
+	"context"
+	"runtime/debug"
+
+	"order-app/internal/logger"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recoverPanic превращает панику обработчика в codes.Internal, как handler.Recovery
+// в HTTP API: без этого паника в одном вызове роняет весь процесс.
+func recoverPanic(ctx context.Context, base *zap.Logger, method string, err *error) {
+	if rec := recover(); rec != nil {
+		logger.FromContext(ctx, base).Error("Паника при обработке gRPC-вызова",
+			zap.String("method", method),
+			zap.Any("panic", rec),
+			zap.ByteString("stack", debug.Stack()),
+		)
+		*err = status.Error(codes.Internal, "Internal server error")
+	}
+}
+
+func unaryRecovery(base *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (_ any, err error) {
+		defer recoverPanic(ctx, base, info.FullMethod, &err)
+		return handler(ctx, req)
+	}
+}
+
+func streamRecovery(base *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer recoverPanic(ss.Context(), base, info.FullMethod, &err)
+		return handler(srv, ss)
+	}
+}
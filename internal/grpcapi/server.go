@@ -0,0 +1,163 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+
+	orderv1 "order-app/api/order/v1"
+	"order-app/internal/auth"
+	"order-app/internal/domain"
+	"order-app/internal/redact"
+	"order-app/internal/repository"
+	"order-app/internal/service"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+	watchBuffer     = 64
+)
+
+type Server struct {
+	orderv1.UnimplementedOrderServiceServer
+
+	svc    *service.OrderService
+	logger *zap.Logger
+	health *health.Server
+	grpc   *grpc.Server
+	done   chan struct{}
+}
+
+func NewServer(svc *service.OrderService, authn *auth.Authenticator, logger *zap.Logger) *Server {
+	s := &Server{
+		svc:    svc,
+		logger: logger,
+		health: health.NewServer(),
+		done:   make(chan struct{}),
+	}
+
+	s.grpc = grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(unaryRecovery(logger), unaryAuth(authn)),
+		grpc.ChainStreamInterceptor(streamRecovery(logger), streamAuth(authn)),
+	)
+	orderv1.RegisterOrderServiceServer(s.grpc, s)
+	healthpb.RegisterHealthServer(s.grpc, s.health)
+	return s
+}
+
+func (s *Server) GRPC() *grpc.Server {
+	return s.grpc
+}
+
+// Shutdown переводит health-статус в NOT_SERVING, завершает WatchOrders и ждёт
+// окончания текущих вызовов. Если ctx истекает раньше, соединения закрываются принудительно.
+func (s *Server) Shutdown(ctx context.Context) {
+	s.health.Shutdown()
+	close(s.done)
+
+	stopped := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.grpc.Stop()
+	}
+}
+
+func (s *Server) GetOrder(ctx context.Context, req *orderv1.GetOrderRequest) (*orderv1.Order, error) {
+	if req.GetOrderUid() == "" {
+		return nil, status.Error(codes.InvalidArgument, "order_uid обязателен")
+	}
+
+	order, err := s.svc.GetOrder(ctx, req.GetOrderUid())
+	if errors.Is(err, repository.ErrOrderNotFound) {
+		return nil, status.Error(codes.NotFound, "Order not found")
+	}
+	if err != nil {
+		s.logger.Error("Не удалось получить заказ", zap.String("order_id", req.GetOrderUid()), zap.Error(err))
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+	return s.render(ctx, order), nil
+}
+
+func (s *Server) ListOrders(ctx context.Context, req *orderv1.ListOrdersRequest) (*orderv1.ListOrdersResponse, error) {
+	pageSize := int(req.GetPageSize())
+	switch {
+	case pageSize < 0:
+		return nil, status.Error(codes.InvalidArgument, "page_size не может быть отрицательным")
+	case pageSize == 0:
+		pageSize = defaultPageSize
+	case pageSize > maxPageSize:
+		pageSize = maxPageSize
+	}
+
+	after, err := base64.RawURLEncoding.DecodeString(req.GetPageToken())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "некорректный page_token")
+	}
+
+	orders, err := s.svc.ListOrders(ctx, string(after), pageSize)
+	if err != nil {
+		s.logger.Error("Не удалось получить список заказов", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	resp := &orderv1.ListOrdersResponse{Orders: make([]*orderv1.Order, 0, len(orders))}
+	for _, o := range orders {
+		resp.Orders = append(resp.Orders, s.render(ctx, o))
+	}
+	if len(orders) == pageSize {
+		resp.NextPageToken = base64.RawURLEncoding.EncodeToString([]byte(orders[len(orders)-1].OrderUID))
+	}
+	return resp, nil
+}
+
+func (s *Server) WatchOrders(req *orderv1.WatchOrdersRequest, stream grpc.ServerStreamingServer[orderv1.Order]) error {
+	ctx := stream.Context()
+	sub := s.svc.Subscribe(service.OrderFilter{
+		DeliveryService: req.GetDeliveryService(),
+		CustomerID:      req.GetCustomerId(),
+	}, watchBuffer)
+	defer sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.done:
+			return status.Error(codes.Unavailable, "сервер завершает работу")
+		case o, ok := <-sub.C():
+			if !ok && sub.Lagged() {
+				return status.Error(codes.ResourceExhausted, "клиент не успевает читать поток заказов")
+			}
+			if !ok {
+				return status.Error(codes.Unavailable, "сервер завершает работу")
+			}
+			if err := stream.Send(s.render(ctx, o)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// render маскирует персональные данные для роли support, как и HTTP API.
+func (s *Server) render(ctx context.Context, o *domain.Order) *orderv1.Order {
+	if p := principalFrom(ctx); p != nil && p.Role == auth.RoleSupport {
+		o = redact.Order(o)
+	}
+	return toProto(o)
+}
@@ -0,0 +1,317 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	orderv1 "order-app/api/order/v1"
+	"order-app/internal/auth"
+	"order-app/internal/cache"
+	"order-app/internal/domain"
+	"order-app/internal/repository"
+	"order-app/internal/repository/storetest"
+	"order-app/internal/service"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	fullKey    = "full-key-0123456789"
+	supportKey = "support-key-0123456789"
+	otherKey   = "other-key-0123456789"
+
+	panicUID = "uid-panic"
+)
+
+// panickingStore паникует на чтении заказа panicUID, остальное берёт из памяти.
+type panickingStore struct {
+	*repository.MemoryOrderRepository
+}
+
+func (s panickingStore) GetOrder(ctx context.Context, uid string) (*domain.Order, error) {
+	if uid == panicUID {
+		panic("сломанный заказ")
+	}
+	return s.MemoryOrderRepository.GetOrder(ctx, uid)
+}
+
+type testEnv struct {
+	svc    *service.OrderService
+	server *Server
+	client orderv1.OrderServiceClient
+	conn   *grpc.ClientConn
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	hash := func(k string) string {
+		d := sha256.Sum256([]byte(k))
+		return "sha256:" + hex.EncodeToString(d[:])
+	}
+	keys, _ := json.Marshal([]map[string]any{
+		{"name": "ops", "hash": hash(fullKey), "scopes": []string{auth.ScopeOrdersRead}},
+		{"name": "support-bot", "hash": hash(supportKey), "role": auth.RoleSupport, "scopes": []string{auth.ScopeOrdersRead}},
+		{"name": "metrics", "hash": hash(otherKey), "scopes": []string{"metrics:read"}},
+	})
+	keysFile := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(keysFile, keys, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	authn, err := auth.NewAuthenticator(auth.Options{APIKeysFile: keysFile})
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+
+	store := panickingStore{repository.NewMemoryOrderRepository()}
+	svc := service.NewOrderService(store, cache.NewCache(time.Minute), zap.NewNop())
+	srv := NewServer(svc, authn, zap.NewNop())
+
+	lis := bufconn.Listen(1 << 20)
+	go srv.GRPC().Serve(lis)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		srv.GRPC().Stop()
+	})
+	return &testEnv{svc: svc, server: srv, client: orderv1.NewOrderServiceClient(conn), conn: conn}
+}
+
+func withKey(ctx context.Context, key string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, auth.APIKeyHeader, key)
+}
+
+func TestAuthMetadata(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	if _, err := env.svc.ProcessOrder(ctx, storetest.NewOrder("uid-1")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want codes.Code
+	}{
+		{"no credentials", ctx, codes.Unauthenticated},
+		{"unknown key", withKey(ctx, "unknown"), codes.Unauthenticated},
+		{"bearer without jwks", metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer x.y.z"), codes.Unauthenticated},
+		{"missing scope", withKey(ctx, otherKey), codes.PermissionDenied},
+		{"api key", withKey(ctx, fullKey), codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := env.client.GetOrder(tt.ctx, &orderv1.GetOrderRequest{OrderUid: "uid-1"})
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("GetOrder: код %s, ожидался %s (%v)", got, tt.want, err)
+			}
+
+			stream, err := env.client.WatchOrders(tt.ctx, &orderv1.WatchOrdersRequest{})
+			if err == nil && tt.want != codes.OK {
+				_, err = stream.Recv()
+				if got := status.Code(err); got != tt.want {
+					t.Fatalf("WatchOrders: код %s, ожидался %s (%v)", got, tt.want, err)
+				}
+			}
+		})
+	}
+
+	// Проверка здоровья доступна без учётных данных.
+	resp, err := healthpb.NewHealthClient(env.conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil || resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("Health/Check: %v, %v", resp, err)
+	}
+}
+
+func TestListOrdersPageToken(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := withKey(context.Background(), fullKey)
+	var want []string
+	for i := range 5 {
+		uid := fmt.Sprintf("uid-%d", i)
+		want = append(want, uid)
+		if _, err := env.svc.ProcessOrder(ctx, storetest.NewOrder(uid)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	token := ""
+	for pages := 0; ; pages++ {
+		if pages > len(want) {
+			t.Fatalf("постраничный обход не завершился: %v", got)
+		}
+		resp, err := env.client.ListOrders(ctx, &orderv1.ListOrdersRequest{PageSize: 2, PageToken: token})
+		if err != nil {
+			t.Fatalf("ListOrders: %v", err)
+		}
+		if len(resp.GetOrders()) > 2 {
+			t.Fatalf("страница больше page_size: %d", len(resp.GetOrders()))
+		}
+		for _, o := range resp.GetOrders() {
+			got = append(got, o.GetOrderUid())
+		}
+		if token = resp.GetNextPageToken(); token == "" {
+			break
+		}
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("заказы %v, ожидались %v", got, want)
+	}
+
+	for _, req := range []*orderv1.ListOrdersRequest{{PageToken: "не base64"}, {PageSize: -1}} {
+		if _, err := env.client.ListOrders(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("ListOrders(%v): %v, ожидался InvalidArgument", req, err)
+		}
+	}
+}
+
+func TestWatchOrders(t *testing.T) {
+	env := newTestEnv(t)
+	ctx, cancel := context.WithCancel(withKey(context.Background(), fullKey))
+	defer cancel()
+
+	stream, err := env.client.WatchOrders(ctx, &orderv1.WatchOrdersRequest{DeliveryService: "meest"})
+	if err != nil {
+		t.Fatalf("WatchOrders: %v", err)
+	}
+	waitFor(t, func() bool { return env.svc.Subscribers() == 1 })
+
+	other := storetest.NewOrder("uid-other")
+	other.DeliveryService = "cdek"
+	match := storetest.NewOrder("uid-match")
+	match.DeliveryService = "meest"
+	for _, o := range []*domain.Order{other, match} {
+		if _, err := env.svc.ProcessOrder(context.Background(), o); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	if got.GetOrderUid() != match.OrderUID {
+		t.Fatalf("получен %q, ожидался %q", got.GetOrderUid(), match.OrderUID)
+	}
+
+	cancel()
+	if _, err := stream.Recv(); status.Code(err) != codes.Canceled {
+		t.Fatalf("после отмены: %v, ожидался Canceled", err)
+	}
+	waitFor(t, func() bool { return env.svc.Subscribers() == 0 })
+}
+
+func TestWatchOrdersShutdown(t *testing.T) {
+	env := newTestEnv(t)
+	stream, err := env.client.WatchOrders(withKey(context.Background(), fullKey), &orderv1.WatchOrdersRequest{})
+	if err != nil {
+		t.Fatalf("WatchOrders: %v", err)
+	}
+	waitFor(t, func() bool { return env.svc.Subscribers() == 1 })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	env.server.Shutdown(ctx)
+
+	if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Fatalf("после Shutdown: %v, ожидался Unavailable", err)
+	}
+}
+
+func TestRenderMasksPIIForSupport(t *testing.T) {
+	env := newTestEnv(t)
+	order := storetest.NewOrder("uid-pii")
+	if _, err := env.svc.ProcessOrder(context.Background(), order); err != nil {
+		t.Fatal(err)
+	}
+	req := &orderv1.GetOrderRequest{OrderUid: order.OrderUID}
+
+	full, err := env.client.GetOrder(withKey(context.Background(), fullKey), req)
+	if err != nil {
+		t.Fatalf("GetOrder: %v", err)
+	}
+	if d := full.GetDelivery(); d.GetName() != order.Delivery.Name || d.GetPhone() != order.Delivery.Phone || d.GetEmail() != order.Delivery.Email {
+		t.Errorf("полный доступ получил изменённые данные: %v", d)
+	}
+
+	masked, err := env.client.GetOrder(withKey(context.Background(), supportKey), req)
+	if err != nil {
+		t.Fatalf("GetOrder: %v", err)
+	}
+	d := masked.GetDelivery()
+	for name, pair := range map[string][2]string{
+		"name":    {d.GetName(), order.Delivery.Name},
+		"phone":   {d.GetPhone(), order.Delivery.Phone},
+		"email":   {d.GetEmail(), order.Delivery.Email},
+		"address": {d.GetAddress(), order.Delivery.Address},
+		"zip":     {d.GetZip(), order.Delivery.Zip},
+	} {
+		if pair[0] == pair[1] || pair[0] == "" {
+			t.Errorf("%s для support не замаскирован: %q", name, pair[0])
+		}
+	}
+
+	// Маскирование не должно менять заказ в кэше.
+	again, err := env.client.GetOrder(withKey(context.Background(), fullKey), req)
+	if err != nil || again.GetDelivery().GetName() != order.Delivery.Name {
+		t.Errorf("после запроса support данные изменились: %v, %v", again.GetDelivery(), err)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := withKey(context.Background(), fullKey)
+
+	_, err := env.client.GetOrder(ctx, &orderv1.GetOrderRequest{OrderUid: panicUID})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("паника в GetOrder: %v, ожидался Internal", err)
+	}
+	if _, err := env.svc.ProcessOrder(ctx, storetest.NewOrder("uid-after-panic")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.client.GetOrder(ctx, &orderv1.GetOrderRequest{OrderUid: "uid-after-panic"}); err != nil {
+		t.Fatalf("сервер не обслуживает вызовы после паники: %v", err)
+	}
+
+	intercept := streamRecovery(zap.NewNop())
+	err = intercept(nil, &authStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/test/Stream"},
+		func(any, grpc.ServerStream) error { panic(errors.New("сломанный поток")) })
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("паника в потоке: %v, ожидался Internal", err)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("условие не выполнилось за 5 с")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,15 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// LogLevel отдаёт и меняет уровень логирования. zap.AtomicLevel сам не выставляет
+// Content-Type, поэтому он задаётся здесь, иначе клиент получит text/plain.
+func LogLevel(level zap.AtomicLevel) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		level.ServeHTTP(c.Writer, c.Request)
+	}
+}
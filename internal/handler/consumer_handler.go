@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+
+	"order-app/internal/kafka"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ConsumerHandler struct {
+	consumer *kafka.Consumer
+}
+
+func NewConsumerHandler(consumer *kafka.Consumer) *ConsumerHandler {
+	return &ConsumerHandler{consumer: consumer}
+}
+
+// Register подключает ручное управление consumer к группе админских маршрутов.
+// Возобновление снимает только ручную паузу: пауза circuit breaker снимается
+// сама, когда хранилище снова доступно.
+func (h *ConsumerHandler) Register(admin *gin.RouterGroup) {
+	admin.GET("/kafka/consumer", h.State)
+	admin.POST("/kafka/consumer/pause", h.Pause)
+	admin.POST("/kafka/consumer/resume", h.Resume)
+}
+
+func (h *ConsumerHandler) State(c *gin.Context) {
+	c.JSON(http.StatusOK, h.consumer.PauseState())
+}
+
+func (h *ConsumerHandler) Pause(c *gin.Context) {
+	h.consumer.Pause(kafka.PauseAdmin)
+	c.JSON(http.StatusOK, h.consumer.PauseState())
+}
+
+func (h *ConsumerHandler) Resume(c *gin.Context) {
+	h.consumer.Resume(kafka.PauseAdmin)
+	c.JSON(http.StatusOK, h.consumer.PauseState())
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"order-app/internal/domain"
+	"order-app/internal/export"
+	"order-app/internal/logger"
+	"order-app/internal/redact"
+	"order-app/internal/repository"
+	"order-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type ExportHandler struct {
+	svc    *service.OrderService
+	logger *zap.Logger
+}
+
+func NewExportHandler(svc *service.OrderService, logger *zap.Logger) *ExportHandler {
+	return &ExportHandler{
+		svc:    svc,
+		logger: logger,
+	}
+}
+
+// Orders стримит выгрузку заказов прямо из курсора БД. Если выгрузка падает
+// посередине, соединение обрывается, чтобы клиент не принял обрезанный файл за полный.
+func (h *ExportHandler) Orders(c *gin.Context) {
+	format, err := export.ParseFormat(c.DefaultQuery("format", string(export.FormatNDJSON)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be ndjson or csv"})
+		return
+	}
+
+	filter, err := parseExportFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	compress := c.Query("compress")
+	if compress != "" && compress != "gzip" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "compress must be gzip"})
+		return
+	}
+
+	filename := "orders-" + time.Now().UTC().Format("20060102T150405Z") + format.Extension()
+	contentType := format.ContentType()
+	var (
+		out io.Writer = c.Writer
+		gz  *gzip.Writer
+	)
+	if compress == "gzip" {
+		gz = gzip.NewWriter(c.Writer)
+		out = gz
+		filename += ".gz"
+		contentType = "application/gzip"
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	w, err := export.NewWriter(format, out)
+	if err != nil {
+		h.abort(c, err)
+		return
+	}
+
+	masked := maskedView(c)
+	count := 0
+	err = h.svc.ExportOrders(c.Request.Context(), filter, func(o *domain.Order) error {
+		if masked {
+			o = redact.Order(o)
+		}
+		count++
+		return w.Write(o)
+	})
+	if err == nil {
+		err = w.Close()
+	}
+	// gzip закрывается только при успехе: без футера обрезанный архив не распакуется.
+	if err == nil && gz != nil {
+		err = gz.Close()
+	}
+	if err != nil {
+		h.abort(c, err)
+		return
+	}
+
+	logger.FromContext(c.Request.Context(), h.logger).Info("Выгрузка заказов завершена",
+		zap.String("format", string(format)),
+		zap.Bool("gzip", compress == "gzip"),
+		zap.Int("orders", count),
+	)
+}
+
+// abort обрывает соединение, не дописывая ответ. Ошибка до этого сохраняется в
+// контексте gin и в спане запроса, а обработчик возвращается штатно, чтобы
+// AccessLog, метрики и otelgin её увидели.
+func (h *ExportHandler) abort(c *gin.Context, err error) {
+	logger.FromContext(c.Request.Context(), h.logger).Error("Выгрузка заказов прервана", zap.Error(err))
+	_ = c.Error(err)
+	span := trace.SpanFromContext(c.Request.Context())
+	span.RecordError(err)
+	span.SetStatus(codes.Error, "выгрузка прервана")
+	c.Abort()
+
+	conn, _, hijackErr := c.Writer.Hijack()
+	if hijackErr != nil {
+		// Соединение HTTP/2 перехватить нельзя, поток сбрасывает только паника.
+		panic(http.ErrAbortHandler)
+	}
+	conn.Close()
+}
+
+func parseExportFilter(c *gin.Context) (repository.ExportFilter, error) {
+	filter := repository.ExportFilter{
+		DeliveryService: c.Query("delivery_service"),
+		CustomerID:      c.Query("customer_id"),
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{
+		{"from", &filter.From},
+		{"to", &filter.To},
+	} {
+		val := c.Query(p.name)
+		if val == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be an RFC 3339 timestamp", p.name)
+		}
+		*p.dst = t
+	}
+	return filter, nil
+}
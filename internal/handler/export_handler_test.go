@@ -0,0 +1,94 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"order-app/internal/cache"
+	"order-app/internal/domain"
+	"order-app/internal/handler"
+	"order-app/internal/repository"
+	"order-app/internal/repository/storetest"
+	"order-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var errCursorLost = errors.New("курсор выгрузки потерян")
+
+// brokenExportStore отдаёт один заказ и падает, как выгрузка, оборвавшаяся посередине.
+type brokenExportStore struct {
+	*repository.MemoryOrderRepository
+}
+
+func (brokenExportStore) ExportOrders(_ context.Context, _ repository.ExportFilter, fn func(*domain.Order) error) error {
+	if err := fn(storetest.NewOrder("uid-before-failure")); err != nil {
+		return err
+	}
+	return errCursorLost
+}
+
+func TestExportAbortKeepsMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := service.NewOrderService(brokenExportStore{repository.NewMemoryOrderRepository()}, cache.NewCache(time.Minute), zap.NewNop())
+
+	for _, query := range []string{"", "?format=csv", "?compress=gzip"} {
+		t.Run("export"+query, func(t *testing.T) {
+			rec := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+			core, logs := observer.New(zap.InfoLevel)
+
+			r := gin.New()
+			r.Use(otelgin.Middleware("test", otelgin.WithTracerProvider(provider)), handler.AccessLog(zap.New(core)))
+			r.GET("/export", handler.NewExportHandler(svc, zap.NewNop()).Orders)
+			srv := httptest.NewServer(r)
+			defer srv.Close()
+
+			resp, err := http.Get(srv.URL + "/export" + query)
+			if err == nil {
+				_, err = io.ReadAll(resp.Body)
+				resp.Body.Close()
+			}
+			if err == nil {
+				t.Fatal("оборванная выгрузка прочитана как полная")
+			}
+
+			// AccessLog и спан завершаются после ответа клиенту, ждём их.
+			deadline := time.Now().Add(5 * time.Second)
+			for logs.Len() == 0 || len(rec.Ended()) == 0 {
+				if time.Now().After(deadline) {
+					t.Fatal("AccessLog или otelgin не отработали")
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			entry := logs.All()[0]
+			if got := entry.ContextMap()["errors"]; !strings.Contains(got.(string), errCursorLost.Error()) {
+				t.Errorf("AccessLog без ошибки выгрузки: %v", entry.ContextMap())
+			}
+			span := rec.Ended()[0]
+			if span.Status().Code != codes.Error {
+				t.Errorf("статус спана %v, ожидался Error", span.Status())
+			}
+			recorded := false
+			for _, ev := range span.Events() {
+				recorded = recorded || ev.Name == "exception"
+			}
+			if !recorded {
+				t.Error("ошибка выгрузки не записана в спан")
+			}
+		})
+	}
+}
@@ -1,8 +1,14 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 
+	"order-app/internal/auth"
+	"order-app/internal/logger"
+	"order-app/internal/redact"
+	"order-app/internal/repository"
 	"order-app/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -25,11 +31,81 @@ func (h *OrderHandler) GetOrderByID(c *gin.Context) {
 	id := c.Param("id")
 
 	order, err := h.svc.GetOrder(c.Request.Context(), id)
-	if err != nil {
-		h.logger.Error("Не удалось получить заказ", zap.String("order_id", id), zap.Error(err))
+	if errors.Is(err, repository.ErrOrderNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 		return
 	}
+	if err != nil {
+		h.log(c).Error("Не удалось получить заказ", zap.String("order_id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	if maskedView(c) {
+		order = redact.Order(order)
+	}
 
 	c.JSON(http.StatusOK, order)
 }
+
+const maxBatchSize = 1000
+
+type batchRequest struct {
+	OrderUIDs []string `json:"order_uids"`
+}
+
+func (h *OrderHandler) GetOrdersBatch(c *gin.Context) {
+	var req batchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if len(req.OrderUIDs) == 0 || len(req.OrderUIDs) > maxBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("order_uids must contain from 1 to %d ids", maxBatchSize)})
+		return
+	}
+
+	orders, missing, err := h.svc.GetOrders(c.Request.Context(), req.OrderUIDs)
+	if err != nil {
+		h.log(c).Error("Не удалось получить заказы пачкой", zap.Int("count", len(req.OrderUIDs)), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	if maskedView(c) {
+		for i, o := range orders {
+			orders[i] = redact.Order(o)
+		}
+	}
+	if missing == nil {
+		missing = []string{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"orders": orders, "missing": missing})
+}
+
+func (h *OrderHandler) GetShardStats(c *gin.Context) {
+	stats, err := h.svc.StorageStats(c.Request.Context())
+	if errors.Is(err, service.ErrStatsUnsupported) {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Storage stats are not available"})
+		return
+	}
+	if err != nil {
+		h.log(c).Error("Не удалось получить статистику шардов", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shards": stats})
+}
+
+func (h *OrderHandler) log(c *gin.Context) *zap.Logger {
+	return logger.FromContext(c.Request.Context(), h.logger)
+}
+
+func maskedView(c *gin.Context) bool {
+	if p := auth.FromContext(c); p != nil && p.Role == auth.RoleSupport {
+		return true
+	}
+	return c.Query("view") == "masked"
+}
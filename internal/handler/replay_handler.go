@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"order-app/internal/kafka"
+	"order-app/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ReplayHandler struct {
+	replayer *kafka.Replayer
+	logger   *zap.Logger
+}
+
+func NewReplayHandler(replayer *kafka.Replayer, logger *zap.Logger) *ReplayHandler {
+	return &ReplayHandler{replayer: replayer, logger: logger}
+}
+
+// Register подключает управление перечитыванием топика к группе админских маршрутов.
+func (h *ReplayHandler) Register(admin *gin.RouterGroup) {
+	admin.POST("/kafka/replay", h.Start)
+	admin.GET("/kafka/replay", h.Status)
+	admin.DELETE("/kafka/replay", h.Cancel)
+}
+
+func (h *ReplayHandler) Start(c *gin.Context) {
+	var opts kafka.ReplayOptions
+	if err := c.ShouldBindJSON(&opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := opts.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Either from or offsets must be set, offsets must be non-negative"})
+		return
+	}
+
+	err := h.replayer.Start(opts)
+	if errors.Is(err, kafka.ErrReplayRunning) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Replay is already running"})
+		return
+	}
+	if err != nil {
+		logger.FromContext(c.Request.Context(), h.logger).Error("Не удалось запустить перечитывание топика", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, h.replayer.Status())
+}
+
+func (h *ReplayHandler) Status(c *gin.Context) {
+	c.JSON(http.StatusOK, h.replayer.Status())
+}
+
+func (h *ReplayHandler) Cancel(c *gin.Context) {
+	h.replayer.Cancel()
+	c.JSON(http.StatusAccepted, h.replayer.Status())
+}
@@ -1,14 +1,27 @@
 package handler
 
 import (
+	"order-app/internal/auth"
+	"order-app/internal/health"
+	"order-app/internal/ratelimit"
 	"order-app/internal/service"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-func RegisterRoutes(r *gin.Engine, svc *service.OrderService, logger *zap.Logger) {
+func RegisterRoutes(r *gin.Engine, svc *service.OrderService, checker *health.Checker, authn *auth.Authenticator, limiter *ratelimit.Limiter, logger *zap.Logger) {
 	orderHandler := NewOrderHandler(svc, logger)
+	healthHandler := NewHealthHandler(checker)
+	streamHandler := NewStreamHandler(svc, logger)
+	exportHandler := NewExportHandler(svc, logger)
 
-	r.GET("/order/:id", orderHandler.GetOrderByID)
+	r.GET("/healthz", healthHandler.Liveness)
+	r.GET("/readyz", healthHandler.Readiness)
+
+	r.GET("/order/:id", limiter.Limit(), authn.Identify(), authn.Require(auth.ScopeOrdersRead), orderHandler.GetOrderByID)
+	r.POST("/orders/batch", limiter.Limit(), authn.Identify(), authn.Require(auth.ScopeOrdersRead), orderHandler.GetOrdersBatch)
+	r.GET("/orders/stream", limiter.Limit(), authn.Identify(), authn.Require(auth.ScopeOrdersRead), streamHandler.Orders)
+	r.GET("/orders/export", limiter.Limit(), authn.Identify(), authn.Require(auth.ScopeOrdersAdmin), exportHandler.Orders)
+	r.GET("/stats/shards", limiter.Limit(), authn.Identify(), authn.Require(auth.ScopeOrdersAdmin), orderHandler.GetShardStats)
 }
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"order-app/internal/domain"
+	"order-app/internal/logger"
+	"order-app/internal/redact"
+	"order-app/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	streamHeartbeat    = 15 * time.Second
+	streamBuffer       = 64
+	streamWriteTimeout = 10 * time.Second
+)
+
+type StreamHandler struct {
+	svc    *service.OrderService
+	logger *zap.Logger
+}
+
+func NewStreamHandler(svc *service.OrderService, logger *zap.Logger) *StreamHandler {
+	return &StreamHandler{
+		svc:    svc,
+		logger: logger,
+	}
+}
+
+// Orders стримит новые заказы в формате Server-Sent Events. События:
+// order (данные заказа, id = order_uid), heartbeat (раз в streamHeartbeat),
+// error (клиент отстал и отключён) и shutdown (сервер останавливается).
+func (h *StreamHandler) Orders(c *gin.Context) {
+	sub := h.svc.Subscribe(service.OrderFilter{
+		DeliveryService: c.Query("delivery_service"),
+		CustomerID:      c.Query("customer_id"),
+	}, streamBuffer)
+	defer sub.Close()
+
+	masked := maskedView(c)
+	rc := http.NewResponseController(c.Writer)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// Медленный клиент не должен держать горутину бесконечно: если запись не
+	// укладывается в таймаут, соединение закрывается.
+	send := func(event, id string, data any) bool {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			h.log(c).Error("Не удалось сериализовать событие потока заказов", zap.Error(err))
+			return false
+		}
+		_ = rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if id != "" {
+			if _, err := fmt.Fprintf(c.Writer, "id: %s\n", id); err != nil {
+				return false
+			}
+		}
+		if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+
+	if !send("heartbeat", "", gin.H{"time": time.Now().UTC()}) {
+		return
+	}
+
+	ticker := time.NewTicker(streamHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case t := <-ticker.C:
+			if !send("heartbeat", "", gin.H{"time": t.UTC()}) {
+				return
+			}
+		case order, ok := <-sub.C():
+			if !ok {
+				if sub.Lagged() {
+					h.log(c).Warn("Клиент потока заказов не успевал читать и был отключён")
+					send("error", "", gin.H{"error": "Client is too slow, reconnect to resume"})
+				} else {
+					send("shutdown", "", gin.H{"error": "Server is shutting down"})
+				}
+				return
+			}
+			if !send("order", order.OrderUID, h.render(order, masked)) {
+				return
+			}
+		}
+	}
+}
+
+func (h *StreamHandler) render(order *domain.Order, masked bool) *domain.Order {
+	if masked {
+		return redact.Order(order)
+	}
+	return order
+}
+
+func (h *StreamHandler) log(c *gin.Context) *zap.Logger {
+	return logger.FromContext(c.Request.Context(), h.logger)
+}
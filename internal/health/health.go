@@ -0,0 +1,122 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrShuttingDown = errors.New("сервис завершает работу")
+
+// degradedError помечает проблему, о которой нужно сообщить в отчёте, но из-за
+// которой сервис не должен выводиться из балансировки.
+type degradedError struct{ error }
+
+func (e degradedError) Unwrap() error { return e.error }
+
+// Degraded оборачивает ошибку проверки так, что она попадает в отчёт со статусом
+// degraded и не делает сервис неготовым.
+func Degraded(err error) error {
+	if err == nil {
+		return nil
+	}
+	return degradedError{err}
+}
+
+type CheckFunc func(ctx context.Context) error
+
+type CheckResult struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+type Report struct {
+	Ready  bool                   `json:"-"`
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type Checker struct {
+	mu           sync.RWMutex
+	checks       map[string]CheckFunc
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{
+		checks:  make(map[string]CheckFunc),
+		timeout: timeout,
+	}
+}
+
+func (c *Checker) Register(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+func (c *Checker) Readiness(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := make(map[string]CheckFunc, len(c.checks))
+	for name, check := range c.checks {
+		checks[name] = check
+	}
+	c.mu.RUnlock()
+
+	report := Report{Ready: true, Checks: make(map[string]CheckResult, len(checks)+1)}
+	if c.shuttingDown.Load() {
+		report.Ready = false
+		report.Checks["shutdown"] = CheckResult{Status: "error", Error: ErrShuttingDown.Error()}
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check CheckFunc) {
+			defer wg.Done()
+			res := c.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = res
+			if res.Status == "error" {
+				report.Ready = false
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	report.Status = "ready"
+	if !report.Ready {
+		report.Status = "not_ready"
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check CheckFunc) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	res := CheckResult{Status: "ok", DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		res.Status = "error"
+		res.Error = err.Error()
+		if errors.As(err, new(degradedError)) {
+			res.Status = "degraded"
+		}
+	}
+	return res
+}
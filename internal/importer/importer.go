@@ -0,0 +1,267 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"order-app/internal/domain"
+	"order-app/internal/validation"
+)
+
+// maxReportedErrors ограничивает число ошибок, сохраняемых в отчёте, чтобы
+// импорт заведомо битого файла не раздувал память.
+const maxReportedErrors = 100
+
+// Store сохраняет проверенный заказ; в сервисе это OrderService.ProcessOrder.
+// false без ошибки означает, что заказ уже был сохранён раньше.
+type Store func(ctx context.Context, order *domain.Order) (bool, error)
+
+// utf8BOM оставляют в начале файла некоторые редакторы.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+type Options struct {
+	DryRun      bool
+	Concurrency int
+}
+
+type RecordError struct {
+	Source   string `json:"source"`
+	OrderUID string `json:"order_uid,omitempty"`
+	Error    string `json:"error"`
+}
+
+// Report — итог импорта. Accepted — сохранённые новые заказы (при DryRun — все
+// валидные записи), Duplicates — заказы, которые уже были в хранилище,
+// Rejected — записи, не прошедшие валидацию, Failed — валидные записи, которые
+// не удалось сохранить.
+type Report struct {
+	Files      int           `json:"files"`
+	Read       int           `json:"read"`
+	Accepted   int           `json:"accepted"`
+	Duplicates int           `json:"duplicates"`
+	Rejected   int           `json:"rejected"`
+	Failed     int           `json:"failed"`
+	DryRun     bool          `json:"dry_run"`
+	Errors     []RecordError `json:"errors,omitempty"`
+}
+
+type Importer struct {
+	validator *validation.OrderValidator
+	store     Store
+	opts      Options
+}
+
+func New(store Store, opts Options) (*Importer, error) {
+	validator, err := validation.NewOrderValidator()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	return &Importer{validator: validator, store: store, opts: opts}, nil
+}
+
+type record struct {
+	source string
+	data   []byte
+}
+
+// Import читает файлы по очереди и обрабатывает записи в opts.Concurrency потоков.
+// Ошибки в отдельных записях попадают в отчёт; ошибка возвращается, только если
+// не удалось прочитать файл или был отменён ctx.
+func (im *Importer) Import(ctx context.Context, paths []string) (*Report, error) {
+	report := &Report{DryRun: im.opts.DryRun}
+	var mu sync.Mutex
+
+	records := make(chan record)
+	var wg sync.WaitGroup
+	for i := 0; i < im.opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rec := range records {
+				uid, result, err := im.process(ctx, rec.data)
+
+				mu.Lock()
+				switch result {
+				case "accepted":
+					report.Accepted++
+				case "duplicate":
+					report.Duplicates++
+				case "rejected":
+					report.Rejected++
+				default:
+					report.Failed++
+				}
+				if err != nil && len(report.Errors) < maxReportedErrors {
+					report.Errors = append(report.Errors, RecordError{Source: rec.source, OrderUID: uid, Error: err.Error()})
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var readErr error
+	for _, path := range paths {
+		report.Files++
+		n, err := readFile(ctx, path, records)
+		report.Read += n
+		if err != nil {
+			readErr = fmt.Errorf("%s: %w", path, err)
+			break
+		}
+	}
+	close(records)
+	wg.Wait()
+
+	return report, readErr
+}
+
+// process возвращает итог записи: accepted, duplicate, rejected или failed.
+func (im *Importer) process(ctx context.Context, data []byte) (uid, result string, err error) {
+	order, err := im.validator.Parse(data)
+	if err != nil {
+		return "", "rejected", err
+	}
+	if im.opts.DryRun {
+		return order.OrderUID, "accepted", nil
+	}
+	inserted, err := im.store(ctx, order)
+	switch {
+	case err != nil:
+		return order.OrderUID, "failed", err
+	case !inserted:
+		return order.OrderUID, "duplicate", nil
+	}
+	return order.OrderUID, "accepted", nil
+}
+
+// readFile отправляет в out записи из файла. Файлы *.ndjson и *.jsonl читаются
+// построчно, и битая строка отклоняется, не мешая остальным. Прочие файлы могут
+// содержать массив JSON, одиночный объект или объекты подряд. Файлы *.gz
+// распаковываются, "-" означает stdin в построчном режиме.
+func readFile(ctx context.Context, path string, out chan<- record) (int, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	name := strings.TrimSuffix(path, ".gz")
+	if name != path {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return 0, fmt.Errorf("не удалось распаковать gzip: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	send := func(source string, data []byte) error {
+		select {
+		case out <- record{source: source, data: data}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	br := bufio.NewReader(r)
+	if name == "-" || strings.HasSuffix(name, ".ndjson") || strings.HasSuffix(name, ".jsonl") {
+		return readLines(path, br, send)
+	}
+	return readJSON(path, br, send)
+}
+
+func readLines(path string, br *bufio.Reader, send func(string, []byte) error) (int, error) {
+	n := 0
+	for line := 1; ; line++ {
+		data, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return n, err
+		}
+		if line == 1 {
+			data = bytes.TrimPrefix(data, utf8BOM)
+		}
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 {
+			n++
+			if serr := send(fmt.Sprintf("%s:%d", path, line), trimmed); serr != nil {
+				return n, serr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return n, nil
+		}
+	}
+}
+
+func readJSON(path string, br *bufio.Reader, send func(string, []byte) error) (int, error) {
+	array, err := startsWithArray(br)
+	if err != nil {
+		return 0, err
+	}
+
+	dec := json.NewDecoder(br)
+	if array {
+		if _, err := dec.Token(); err != nil {
+			return 0, fmt.Errorf("не удалось прочитать JSON: %w", err)
+		}
+	}
+
+	n := 0
+	for {
+		if array && !dec.More() {
+			return n, nil
+		}
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) && !array {
+				return n, nil
+			}
+			return n, fmt.Errorf("запись #%d: не удалось прочитать JSON: %w", n+1, err)
+		}
+		n++
+
+		if err := send(fmt.Sprintf("%s#%d", path, n), raw); err != nil {
+			return n, err
+		}
+	}
+}
+
+func startsWithArray(br *bufio.Reader) (bool, error) {
+	for {
+		b, err := br.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case utf8BOM[0]:
+			// Одиночный 0xEF — не BOM: его отвергнет разбор JSON.
+			if rest, _ := br.Peek(2); bytes.Equal(rest, utf8BOM[1:]) {
+				br.Discard(2)
+				continue
+			}
+		}
+		return b == '[', br.UnreadByte()
+	}
+}
@@ -0,0 +1,235 @@
+package importer_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"order-app/internal/domain"
+	"order-app/internal/importer"
+	"order-app/internal/repository/storetest"
+)
+
+const failingUID = "uid-store-fails"
+
+// memStore сохраняет uid заказов; failingUID не сохраняется никогда.
+type memStore struct {
+	mu    sync.Mutex
+	saved map[string]bool
+}
+
+func newMemStore(existing ...string) *memStore {
+	s := &memStore{saved: make(map[string]bool)}
+	for _, uid := range existing {
+		s.saved[uid] = true
+	}
+	return s
+}
+
+func (s *memStore) save(_ context.Context, o *domain.Order) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if o.OrderUID == failingUID {
+		return false, errors.New("хранилище недоступно")
+	}
+	if s.saved[o.OrderUID] {
+		return false, nil
+	}
+	s.saved[o.OrderUID] = true
+	return true, nil
+}
+
+func orderJSON(t *testing.T, uid string) string {
+	t.Helper()
+	data, err := json.Marshal(storetest.NewOrder(uid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	data := []byte(content)
+	if strings.HasSuffix(name, ".gz") {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write(data)
+		gz.Close()
+		data = buf.Bytes()
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestImport(t *testing.T) {
+	a, b, c := orderJSON(t, "uid-a"), orderJSON(t, "uid-b"), orderJSON(t, "uid-c")
+	bom := string([]byte{0xEF, 0xBB, 0xBF})
+
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		existing []string
+		want     importer.Report
+		wantErr  bool
+	}{
+		{
+			name:    "json array",
+			file:    "orders.json",
+			content: "[\n" + a + ",\n" + b + "\n]",
+			want:    importer.Report{Read: 2, Accepted: 2},
+		},
+		{
+			name:    "single object",
+			file:    "order.json",
+			content: a,
+			want:    importer.Report{Read: 1, Accepted: 1},
+		},
+		{
+			name:    "concatenated objects",
+			file:    "orders.json",
+			content: a + "\n" + b + c,
+			want:    importer.Report{Read: 3, Accepted: 3},
+		},
+		{
+			name:    "ndjson",
+			file:    "orders.ndjson",
+			content: a + "\n\n" + b + "\n" + c,
+			want:    importer.Report{Read: 3, Accepted: 3},
+		},
+		{
+			name:    "jsonl gzip",
+			file:    "orders.jsonl.gz",
+			content: a + "\n" + b + "\n",
+			want:    importer.Report{Read: 2, Accepted: 2},
+		},
+		{
+			name:    "json array with bom",
+			file:    "orders.json",
+			content: bom + " [" + a + "]",
+			want:    importer.Report{Read: 1, Accepted: 1},
+		},
+		{
+			name:    "ndjson with bom",
+			file:    "orders.ndjson",
+			content: bom + a + "\n" + b,
+			want:    importer.Report{Read: 2, Accepted: 2},
+		},
+		{
+			name:    "truncated bom",
+			file:    "orders.json",
+			content: "\xEF\xBB[" + a + "]",
+			want:    importer.Report{},
+			wantErr: true,
+		},
+		{
+			name:    "invalid ndjson rows",
+			file:    "orders.ndjson",
+			content: a + "\n{not json\n" + `{"order_uid":"uid-empty"}` + "\n" + b,
+			want:    importer.Report{Read: 4, Accepted: 2, Rejected: 2},
+		},
+		{
+			name:    "invalid row in array",
+			file:    "orders.json",
+			content: "[" + a + `,{"order_uid":"uid-empty"}]`,
+			want:    importer.Report{Read: 2, Accepted: 1, Rejected: 1},
+		},
+		{
+			name:    "broken json",
+			file:    "orders.json",
+			content: "[" + a + ", {",
+			want:    importer.Report{Read: 1, Accepted: 1},
+			wantErr: true,
+		},
+		{
+			name:     "duplicates",
+			file:     "orders.ndjson",
+			content:  a + "\n" + b + "\n" + b + "\n" + c,
+			existing: []string{"uid-a"},
+			want:     importer.Report{Read: 4, Accepted: 2, Duplicates: 2},
+		},
+		{
+			name:    "store failure",
+			file:    "orders.ndjson",
+			content: a + "\n" + orderJSON(t, failingUID),
+			want:    importer.Report{Read: 2, Accepted: 1, Failed: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, tt.file, tt.content)
+			im, err := importer.New(newMemStore(tt.existing...).save, importer.Options{Concurrency: 2})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			report, err := im.Import(context.Background(), []string{path})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Import: ошибка %v, ожидалась: %v", err, tt.wantErr)
+			}
+			tt.want.Files = 1
+			errs := report.Errors
+			report.Errors = nil
+			if !reflect.DeepEqual(*report, tt.want) {
+				t.Errorf("отчёт %+v, ожидался %+v", *report, tt.want)
+			}
+			if len(errs) != tt.want.Rejected+tt.want.Failed {
+				t.Errorf("ошибок в отчёте %d: %+v", len(errs), errs)
+			}
+			for _, e := range errs {
+				if !strings.HasPrefix(e.Source, path) {
+					t.Errorf("источник ошибки %q не указывает на файл", e.Source)
+				}
+			}
+		})
+	}
+}
+
+func TestImportDryRun(t *testing.T) {
+	path := writeFile(t, "orders.ndjson", orderJSON(t, "uid-a")+"\n{}\n")
+	store := func(context.Context, *domain.Order) (bool, error) {
+		t.Fatal("при DryRun заказы не сохраняются")
+		return false, nil
+	}
+	im, err := importer.New(store, importer.Options{DryRun: true})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	report, err := im.Import(context.Background(), []string{path})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if !report.DryRun || report.Accepted != 1 || report.Rejected != 1 {
+		t.Errorf("отчёт %+v", *report)
+	}
+}
+
+func TestImportReportsSources(t *testing.T) {
+	path := writeFile(t, "orders.ndjson", orderJSON(t, "uid-a")+"\n\n{}\n")
+	im, _ := importer.New(newMemStore().save, importer.Options{})
+	report, err := im.Import(context.Background(), []string{path})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	sources := make([]string, 0, len(report.Errors))
+	for _, e := range report.Errors {
+		sources = append(sources, e.Source)
+	}
+	if !slices.Equal(sources, []string{path + ":3"}) {
+		t.Errorf("источники ошибок %v, ожидалась третья строка", sources)
+	}
+}
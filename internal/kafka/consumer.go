@@ -2,46 +2,90 @@ package kafka
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"slices"
+	"strconv"
+	"sync"
 	"time"
 
 	"order-app/config"
-	"order-app/internal/domain"
+	"order-app/internal/health"
+	"order-app/internal/metrics"
+	"order-app/internal/repository"
 	"order-app/internal/service"
+	"order-app/internal/tracing"
+	"order-app/internal/validation"
 
 	"github.com/segmentio/kafka-go"
-	"github.com/xeipuuv/gojsonschema"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+// Сообщение, которое не удалось сохранить из-за временного сбоя хранилища, не
+// коммитится: оно обрабатывается повторно с растущей задержкой, пока хранилище не
+// ответит или consumer не остановят.
+const (
+	consumerRetryBaseDelay = 500 * time.Millisecond
+	consumerRetryMaxDelay  = 30 * time.Second
+)
+
 type Consumer struct {
 	r        *kafka.Reader
+	dialer   *kafka.Dialer
+	brokers  []string
+	maxLag   int64
 	svc      *service.OrderService
 	logger   *zap.Logger
-	schema   *gojsonschema.Schema
+	orders   *validation.OrderValidator
+	metrics  *metrics.Metrics
 	stopChan chan struct{}
+
+	// lags — последнее известное отставание по партициям (int -> int64). Reader.Lag()
+	// для consumer group всегда возвращает -1, поэтому отставание считаем сами.
+	lags sync.Map
+
+	mu      sync.Mutex
+	paused  map[string]time.Time
+	resumed chan struct{}
 }
 
-func NewConsumer(cfg *config.Config, svc *service.OrderService, log *zap.Logger) (*Consumer, error) {
-	schemaLoader := gojsonschema.NewStringLoader(orderSchemaJSON)
-	schema, err := gojsonschema.NewSchema(schemaLoader)
+func NewConsumer(cfg *config.Config, svc *service.OrderService, log *zap.Logger, m *metrics.Metrics) (*Consumer, error) {
+	orders, err := validation.NewOrderValidator()
+	if err != nil {
+		return nil, err
+	}
+
+	dialer, err := NewDialer(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("не удалось загрузить JSON-схему: %w", err)
+		return nil, err
 	}
 
 	c := &Consumer{
 		r: kafka.NewReader(kafka.ReaderConfig{
-			Brokers:  []string{cfg.KafkaBrokers},
-			Topic:    cfg.KafkaTopic,
-			GroupID:  cfg.KafkaGroupID,
-			MinBytes: 10e3, // 10KB
-			MaxBytes: 10e6, // 10MB
+			Brokers:        cfg.KafkaBrokers,
+			Topic:          cfg.KafkaTopic,
+			GroupID:        cfg.KafkaGroupID,
+			Dialer:         dialer,
+			MinBytes:       int(cfg.KafkaMinBytes),
+			MaxBytes:       int(cfg.KafkaMaxBytes),
+			MaxWait:        cfg.KafkaMaxWait,
+			StartOffset:    startOffset(cfg.KafkaStartOffset),
+			SessionTimeout: cfg.KafkaSessionTimeout,
 		}),
+		dialer:   dialer,
+		brokers:  cfg.KafkaBrokers,
+		maxLag:   cfg.KafkaReadyMaxLag,
 		svc:      svc,
 		logger:   log,
-		schema:   schema,
+		orders:   orders,
+		metrics:  m,
 		stopChan: make(chan struct{}),
+		paused:   make(map[string]time.Time),
+	}
+	for _, reason := range []string{PauseAdmin, PauseBreaker} {
+		m.ConsumerPaused.WithLabelValues(reason).Set(0)
 	}
 	return c, nil
 }
@@ -56,116 +100,162 @@ func (c *Consumer) Start(ctx context.Context) {
 			c.logger.Info("Получен сигнал остановки для consumer")
 			return
 		default:
-			m, err := c.r.ReadMessage(ctx)
+			if !c.waitResumed(ctx) {
+				continue
+			}
+			m, err := c.r.FetchMessage(ctx)
 			if err != nil {
 				c.logger.Error("Ошибка при чтении сообщения из Kafka", zap.Error(err))
 				time.Sleep(1 * time.Second)
 				continue
 			}
 
-			order, err := c.validateAndParse(m.Value)
-			if err != nil {
-				c.logger.Error("Получено некорректное сообщение", zap.Error(err))
+			if !c.process(ctx, m) {
 				continue
 			}
-
-			if err := c.svc.ProcessOrder(ctx, order); err != nil {
-				c.logger.Error("Не удалось обработать заказ", zap.Error(err))
-			} else {
-				c.logger.Info("Заказ успешно обработан", zap.String("order_uid", order.OrderUID))
+			if err := c.r.CommitMessages(ctx, m); err != nil {
+				c.logger.Error("Не удалось закоммитить смещение в Kafka",
+					zap.Int("partition", m.Partition), zap.Int64("offset", m.Offset), zap.Error(err))
 			}
 		}
 	}
 }
 
-func (c *Consumer) Stop() {
-	close(c.stopChan)
-	c.r.Close()
+// process обрабатывает сообщение до успешного сохранения или до признания его
+// некорректным. Постоянную ошибку хранилища (см. repository.IsTransient) повтор не
+// исправит, поэтому такое сообщение пишется в лог и пропускается, иначе оно
+// навсегда остановило бы партицию. Возвращает false, если consumer остановлен
+// раньше: тогда сообщение не коммитится и после перезапуска будет прочитано снова.
+func (c *Consumer) process(ctx context.Context, m kafka.Message) bool {
+	delay := consumerRetryBaseDelay
+	for c.waitResumed(ctx) {
+		err := c.handleMessage(ctx, m)
+		if err == nil {
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+		if !repository.IsTransient(err) {
+			c.metrics.MessagesProcessed.WithLabelValues(m.Topic, strconv.Itoa(m.Partition), "dropped").Inc()
+			c.logger.Error("Заказ не может быть сохранён, сообщение пропущено",
+				zap.Int("partition", m.Partition), zap.Int64("offset", m.Offset), zap.Error(err))
+			return true
+		}
+		c.logger.Warn("Сообщение будет обработано повторно",
+			zap.Int("partition", m.Partition), zap.Int64("offset", m.Offset),
+			zap.Duration("delay", delay), zap.Error(err))
+		if !c.sleep(ctx, delay) {
+			return false
+		}
+		delay = min(delay*2, consumerRetryMaxDelay)
+	}
+	return false
 }
 
-func (c *Consumer) validateAndParse(data []byte) (*domain.Order, error) {
-	result, err := c.schema.Validate(gojsonschema.NewBytesLoader(data))
+// sleep ждёт d и возвращает false, если consumer остановлен раньше.
+func (c *Consumer) sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-c.stopChan:
+		return false
+	}
+}
+
+// handleMessage возвращает ошибку, только если корректный заказ не удалось сохранить.
+func (c *Consumer) handleMessage(ctx context.Context, m kafka.Message) error {
+	start := time.Now()
+	partition := strconv.Itoa(m.Partition)
+	c.metrics.MessagesConsumed.WithLabelValues(m.Topic, partition).Inc()
+	lag := max(m.HighWaterMark-m.Offset-1, 0)
+	c.lags.Store(m.Partition, lag)
+	c.metrics.ConsumerLag.WithLabelValues(m.Topic, partition).Set(float64(lag))
+	defer func() {
+		c.metrics.ProcessingLatency.WithLabelValues(m.Topic).Observe(time.Since(start).Seconds())
+	}()
+
+	ctx = otel.GetTextMapPropagator().Extract(ctx, headerCarrier{msg: &m})
+	ctx, span := tracing.Tracer().Start(ctx, "kafka.consume "+m.Topic,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", m.Topic),
+			attribute.Int("messaging.kafka.destination.partition", m.Partition),
+			attribute.Int64("messaging.kafka.message.offset", m.Offset),
+		),
+	)
+	var err error
+	defer func() { tracing.End(span, err) }()
+
+	_, validateSpan := tracing.Start(ctx, "order.validate")
+	order, err := c.orders.Parse(m.Value)
+	tracing.End(validateSpan, err)
 	if err != nil {
-		return nil, fmt.Errorf("не удалось валидировать JSON: %w", err)
+		c.metrics.MessagesProcessed.WithLabelValues(m.Topic, partition, "invalid").Inc()
+		c.logger.Error("Получено некорректное сообщение", zap.Error(err))
+		return nil
 	}
-	if !result.Valid() {
-		return nil, fmt.Errorf("JSON не соответствует схеме: %v", result.Errors())
+	c.metrics.MessagesProcessed.WithLabelValues(m.Topic, partition, "valid").Inc()
+	span.SetAttributes(attribute.String("order.uid", order.OrderUID))
+
+	if _, err = c.svc.ProcessOrder(ctx, order); err != nil {
+		c.metrics.MessagesProcessed.WithLabelValues(m.Topic, partition, "failed").Inc()
+		c.logger.Error("Не удалось обработать заказ", zap.Error(err))
+		return err
 	}
 
-	var order domain.Order
-	if err := json.Unmarshal(data, &order); err != nil {
-		return nil, fmt.Errorf("не удалось распарсить заказ: %w", err)
+	c.logger.Info("Заказ успешно обработан", zap.String("order_uid", order.OrderUID))
+	return nil
+}
+
+func (c *Consumer) HealthCheck(ctx context.Context) error {
+	var lastErr error
+	for _, broker := range c.brokers {
+		conn, err := c.dialer.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		conn.Close()
+		lastErr = nil
+		break
+	}
+	if lastErr != nil {
+		return fmt.Errorf("брокеры Kafka недоступны: %w", lastErr)
 	}
 
-	return &order, nil
+	if lag := c.Lag(); c.maxLag > 0 && lag > c.maxLag {
+		return fmt.Errorf("отставание consumer %d превышает допустимое %d", lag, c.maxLag)
+	}
+	if st := c.PauseState(); st.Paused {
+		err := fmt.Errorf("чтение приостановлено: %s", pauseReasons(st))
+		// Пауза circuit breaker означает, что хранилище недоступно и заказы не
+		// сохраняются, поэтому сервис выводится из балансировки. Пауза администратора
+		// осознанная, а чтение заказов через API продолжает работать.
+		if slices.Contains(st.Reasons, PauseBreaker) {
+			return err
+		}
+		return health.Degraded(err)
+	}
+	return nil
 }
 
-const orderSchemaJSON = `
-{
-  "$schema": "http://json-schema.org/draft-07/schema#",
-  "type": "object",
-  "required": ["order_uid", "track_number", "entry", "delivery", "payment", "items", "locale", "customer_id", "delivery_service", "shardkey", "sm_id", "date_created", "oof_shard"],
-  "properties": {
-    "order_uid": {"type": "string"},
-    "track_number": {"type": "integer"},
-    "entry": {"type": "string"},
-    "delivery": {
-      "type": "object",
-      "required": ["name", "phone", "zip", "city", "address", "region", "email"],
-      "properties": {
-        "name": {"type": "string"},
-        "phone": {"type": "string"},
-        "zip": {"type": "string"},
-        "city": {"type": "string"},
-        "address": {"type": "string"},
-        "region": {"type": "string"},
-        "email": {"type": "string", "format": "email"}
-      }
-    },
-    "payment": {
-      "type": "object",
-      "required": ["transaction", "currency", "provider", "amount", "payment_dt", "bank", "delivery_cost", "goods_total", "custom_fee"],
-      "properties": {
-        "transaction": {"type": "string"},
-        "request_id": {"type": "string"},
-        "currency": {"type": "string"},
-        "provider": {"type": "string"},
-        "amount": {"type": "integer"},
-        "payment_dt": {"type": "integer"},
-        "bank": {"type": "string"},
-        "delivery_cost": {"type": "integer"},
-        "goods_total": {"type": "integer"},
-        "custom_fee": {"type": "integer"}
-      }
-    },
-    "items": {
-      "type": "array",
-      "items": {
-        "type": "object",
-        "required": ["chrt_id", "track_number", "price", "rid", "name", "sale", "size", "total_price", "nm_id", "brand", "status"],
-        "properties": {
-          "chrt_id": {"type": "integer"},
-          "track_number": {"type": "string"},
-          "price": {"type": "integer"},
-          "rid": {"type": "string"},
-          "name": {"type": "string"},
-          "sale": {"type": "integer"},
-          "size": {"type": "string"},
-          "total_price": {"type": "integer"},
-          "nm_id": {"type": "integer"},
-          "brand": {"type": "string"},
-          "status": {"type": "integer"}
-        }
-      }
-    },
-    "locale": {"type": "string"},
-    "internal_signature": {"type": "string"},
-    "customer_id": {"type": "string"},
-    "delivery_service": {"type": "string"},
-    "shardkey": {"type": "string"},
-    "sm_id": {"type": "integer"},
-    "date_created": {"type": "string", "format": "date-time"},
-    "oof_shard": {"type": "string"}
-  }
+// Lag возвращает суммарное отставание по всем партициям, из которых уже читались сообщения.
+func (c *Consumer) Lag() int64 {
+	var total int64
+	c.lags.Range(func(_, lag any) bool {
+		total += lag.(int64)
+		return true
+	})
+	return total
+}
+
+func (c *Consumer) Stop() {
+	close(c.stopChan)
+	c.r.Close()
 }
-`
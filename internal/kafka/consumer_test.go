@@ -0,0 +1,168 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"order-app/config"
+	"order-app/internal/cache"
+	"order-app/internal/domain"
+	"order-app/internal/metrics"
+	"order-app/internal/repository"
+	"order-app/internal/repository/storetest"
+	"order-app/internal/service"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+)
+
+func TestConsumeSpans(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	svc := service.NewOrderService(repository.NewMemoryOrderRepository(), cache.NewCache(time.Minute), zap.NewNop())
+	cfg := &config.Config{KafkaBrokers: []string{"127.0.0.1:9092"}, KafkaTopic: "orders", KafkaGroupID: "test"}
+	c, err := NewConsumer(cfg, svc, zap.NewNop(), metrics.New())
+	if err != nil {
+		t.Fatalf("NewConsumer: %v", err)
+	}
+	defer c.r.Close()
+
+	value, err := json.Marshal(storetest.NewOrder("uid-kafka-trace"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := kafka.Message{Topic: "orders", Partition: 0, Offset: 1, HighWaterMark: 2, Value: value}
+
+	// Продюсер кладёт контекст трассы в заголовки сообщения.
+	producerCtx, producer := provider.Tracer("producer").Start(context.Background(), "kafka.produce orders")
+	otel.GetTextMapPropagator().Inject(producerCtx, headerCarrier{msg: &msg})
+	producer.End()
+
+	if err := c.handleMessage(context.Background(), msg); err != nil {
+		t.Fatalf("handleMessage: %v", err)
+	}
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range rec.Ended() {
+		spans[s.Name()] = s
+	}
+	for child, parent := range map[string]string{
+		"kafka.consume orders":      "kafka.produce orders",
+		"order.validate":            "kafka.consume orders",
+		"OrderService.ProcessOrder": "kafka.consume orders",
+		"OrderCache.Set":            "OrderService.ProcessOrder",
+	} {
+		cs, ps := spans[child], spans[parent]
+		if cs == nil || ps == nil {
+			t.Fatalf("не записан спан %q или %q", child, parent)
+		}
+		if cs.Parent().SpanID() != ps.SpanContext().SpanID() || cs.SpanContext().TraceID() != ps.SpanContext().TraceID() {
+			t.Errorf("спан %q должен быть дочерним для %q", child, parent)
+		}
+	}
+}
+
+func newTestConsumer(t *testing.T, store repository.OrderStore) *Consumer {
+	t.Helper()
+	svc := service.NewOrderService(store, cache.NewCache(time.Minute), zap.NewNop())
+	cfg := &config.Config{KafkaBrokers: []string{"127.0.0.1:9092"}, KafkaTopic: "orders", KafkaGroupID: "test"}
+	c, err := NewConsumer(cfg, svc, zap.NewNop(), metrics.New())
+	if err != nil {
+		t.Fatalf("NewConsumer: %v", err)
+	}
+	t.Cleanup(func() { c.r.Close() })
+	return c
+}
+
+func TestConsumerRetriesStorageError(t *testing.T) {
+	store := &flakyStore{MemoryOrderRepository: repository.NewMemoryOrderRepository()}
+	store.failures.Store(1)
+	c := newTestConsumer(t, store)
+
+	if !c.process(context.Background(), orderMessage(t, "uid-consume-retry")) {
+		t.Fatal("сообщение должно быть обработано после повтора")
+	}
+	if got := store.calls.Load(); got != 2 {
+		t.Fatalf("SaveOrder вызван %d раз, ожидалось 2", got)
+	}
+}
+
+func TestConsumerDoesNotCommitOnStop(t *testing.T) {
+	store := &flakyStore{MemoryOrderRepository: repository.NewMemoryOrderRepository()}
+	store.failures.Store(1000)
+	c := newTestConsumer(t, store)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if c.process(ctx, orderMessage(t, "uid-consume-stop")) {
+		t.Fatal("несохранённое сообщение не должно считаться обработанным")
+	}
+}
+
+func TestConsumerSkipsInvalidMessage(t *testing.T) {
+	store := &flakyStore{MemoryOrderRepository: repository.NewMemoryOrderRepository()}
+	c := newTestConsumer(t, store)
+
+	if !c.process(context.Background(), kafka.Message{Topic: "orders", Value: []byte("{")}) {
+		t.Fatal("некорректное сообщение должно коммититься без повторов")
+	}
+	if got := store.calls.Load(); got != 0 {
+		t.Fatalf("некорректное сообщение дошло до хранилища: %d", got)
+	}
+}
+
+// errInvalidData — ответ Postgres на \u0000 в jsonb.
+var errInvalidData = &pgconn.PgError{Code: "22P05", Message: "unsupported Unicode escape sequence"}
+
+// failingStore всегда отказывает в сохранении с ошибкой err.
+type failingStore struct {
+	*repository.MemoryOrderRepository
+	err   error
+	calls atomic.Int32
+}
+
+func (s *failingStore) SaveOrder(context.Context, *domain.Order) (bool, error) {
+	s.calls.Add(1)
+	return false, s.err
+}
+
+func TestConsumerSkipsPermanentStorageError(t *testing.T) {
+	for name, err := range map[string]error{
+		"pg data error":  fmt.Errorf("не удалось вставить заказ в БД: %w", errInvalidData),
+		"unique":         &pgconn.PgError{Code: "23505"},
+		"invalid order":  fmt.Errorf("не удалось вставить заказ в БД: %w", repository.ErrInvalidOrder),
+		"encoding error": errors.New("не удалось подготовить заказ к сохранению"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			store := &failingStore{MemoryOrderRepository: repository.NewMemoryOrderRepository(), err: err}
+			c := newTestConsumer(t, store)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if !c.process(ctx, orderMessage(t, "uid-consume-permanent")) {
+				t.Fatal("сообщение с постоянной ошибкой должно коммититься")
+			}
+			if got := store.calls.Load(); got != 1 {
+				t.Fatalf("постоянная ошибка не должна повторяться, SaveOrder вызван %d раз", got)
+			}
+		})
+	}
+}
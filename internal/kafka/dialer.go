@@ -0,0 +1,92 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"order-app/config"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// NewDialer собирает подключение к брокерам с учётом client ID, TLS и SASL из
+// конфигурации. Им пользуются и consumer, и перечитывание топика.
+func NewDialer(cfg *config.Config) (*kafka.Dialer, error) {
+	d := &kafka.Dialer{
+		ClientID:  cfg.KafkaClientID,
+		Timeout:   10 * time.Second,
+		DualStack: true,
+	}
+
+	if cfg.KafkaTLSEnabled {
+		tlsCfg, err := kafkaTLSConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		d.TLS = tlsCfg
+	}
+
+	mechanism, err := saslMechanism(cfg)
+	if err != nil {
+		return nil, err
+	}
+	d.SASLMechanism = mechanism
+	return d, nil
+}
+
+func kafkaTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.KafkaTLSSkipVerify,
+	}
+
+	if cfg.KafkaTLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.KafkaTLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать CA для Kafka: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("в KAFKA_TLS_CA_FILE не найдено ни одного сертификата")
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if cfg.KafkaTLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.KafkaTLSCertFile, cfg.KafkaTLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось загрузить клиентский сертификат для Kafka: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return tlsCfg, nil
+}
+
+func saslMechanism(cfg *config.Config) (sasl.Mechanism, error) {
+	switch cfg.KafkaSASLMechanism {
+	case "":
+		return nil, nil
+	case "PLAIN":
+		return plain.Mechanism{Username: cfg.KafkaSASLUsername, Password: cfg.KafkaSASLPassword}, nil
+	case "SCRAM-SHA-256":
+		return scram.Mechanism(scram.SHA256, cfg.KafkaSASLUsername, cfg.KafkaSASLPassword)
+	case "SCRAM-SHA-512":
+		return scram.Mechanism(scram.SHA512, cfg.KafkaSASLUsername, cfg.KafkaSASLPassword)
+	default:
+		return nil, fmt.Errorf("неизвестный механизм SASL %q", cfg.KafkaSASLMechanism)
+	}
+}
+
+func startOffset(val string) int64 {
+	if val == "last" {
+		return kafka.LastOffset
+	}
+	return kafka.FirstOffset
+}
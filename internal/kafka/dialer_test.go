@@ -0,0 +1,263 @@
+package kafka
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"order-app/config"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+)
+
+// tlsFiles — самоподписанный CA и клиентский сертификат с ключом в PEM-файлах.
+type tlsFiles struct {
+	ca, cert, key, otherKey string
+}
+
+func writeTLSFiles(t *testing.T) tlsFiles {
+	t.Helper()
+	dir := t.TempDir()
+
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	write := func(name, typ string, der []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	writeKey := func(name string, key *ecdsa.PrivateKey) string {
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return write(name, "EC PRIVATE KEY", der)
+	}
+
+	caKey := newKey()
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientKey := newKey()
+	clientTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "order-app"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	clientDER, err := x509.CreateCertificate(rand.Reader, clientTmpl, caTmpl, &clientKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tlsFiles{
+		ca:       write("ca.pem", "CERTIFICATE", caDER),
+		cert:     write("client.pem", "CERTIFICATE", clientDER),
+		key:      writeKey("client.key", clientKey),
+		otherKey: writeKey("other.key", newKey()),
+	}
+}
+
+func TestNewDialerTLS(t *testing.T) {
+	files := writeTLSFiles(t)
+	garbage := filepath.Join(t.TempDir(), "garbage.pem")
+	if err := os.WriteFile(garbage, []byte("не сертификат"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*config.Config)
+		check   func(*testing.T, *tls.Config)
+		wantErr string
+	}{
+		{
+			name:   "disabled",
+			modify: func(*config.Config) {},
+			check: func(t *testing.T, c *tls.Config) {
+				if c != nil {
+					t.Errorf("TLS включён без KAFKA_TLS_ENABLED: %+v", c)
+				}
+			},
+		},
+		{
+			name:   "system roots",
+			modify: func(c *config.Config) { c.KafkaTLSEnabled = true },
+			check: func(t *testing.T, c *tls.Config) {
+				if c == nil || c.RootCAs != nil || len(c.Certificates) != 0 || c.InsecureSkipVerify || c.MinVersion != tls.VersionTLS12 {
+					t.Errorf("TLS по умолчанию: %+v", c)
+				}
+			},
+		},
+		{
+			name: "ca file",
+			modify: func(c *config.Config) {
+				c.KafkaTLSEnabled = true
+				c.KafkaTLSCAFile = files.ca
+			},
+			check: func(t *testing.T, c *tls.Config) {
+				if c == nil || c.RootCAs == nil || c.RootCAs.Equal(x509.NewCertPool()) {
+					t.Errorf("CA не загружен: %+v", c)
+				}
+			},
+		},
+		{
+			name: "client cert",
+			modify: func(c *config.Config) {
+				c.KafkaTLSEnabled = true
+				c.KafkaTLSCertFile = files.cert
+				c.KafkaTLSKeyFile = files.key
+			},
+			check: func(t *testing.T, c *tls.Config) {
+				if c == nil || len(c.Certificates) != 1 {
+					t.Errorf("клиентский сертификат не загружен: %+v", c)
+				}
+			},
+		},
+		{
+			name: "skip verify",
+			modify: func(c *config.Config) {
+				c.KafkaTLSEnabled = true
+				c.KafkaTLSSkipVerify = true
+			},
+			check: func(t *testing.T, c *tls.Config) {
+				if c == nil || !c.InsecureSkipVerify {
+					t.Errorf("InsecureSkipVerify не выставлен: %+v", c)
+				}
+			},
+		},
+		{
+			name: "missing ca file",
+			modify: func(c *config.Config) {
+				c.KafkaTLSEnabled = true
+				c.KafkaTLSCAFile = filepath.Join(t.TempDir(), "missing.pem")
+			},
+			wantErr: "не удалось прочитать CA",
+		},
+		{
+			name: "ca file without certificates",
+			modify: func(c *config.Config) {
+				c.KafkaTLSEnabled = true
+				c.KafkaTLSCAFile = garbage
+			},
+			wantErr: "не найдено ни одного сертификата",
+		},
+		{
+			name: "key does not match cert",
+			modify: func(c *config.Config) {
+				c.KafkaTLSEnabled = true
+				c.KafkaTLSCertFile = files.cert
+				c.KafkaTLSKeyFile = files.otherKey
+			},
+			wantErr: "клиентский сертификат",
+		},
+		{
+			name: "missing key file",
+			modify: func(c *config.Config) {
+				c.KafkaTLSEnabled = true
+				c.KafkaTLSCertFile = files.cert
+				c.KafkaTLSKeyFile = filepath.Join(t.TempDir(), "missing.key")
+			},
+			wantErr: "клиентский сертификат",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{KafkaClientID: "order-app-test"}
+			tt.modify(cfg)
+			d, err := NewDialer(cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NewDialer: %v, ожидалась ошибка с %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewDialer: %v", err)
+			}
+			if d.ClientID != "order-app-test" || d.Timeout <= 0 {
+				t.Errorf("ClientID %q, Timeout %s", d.ClientID, d.Timeout)
+			}
+			tt.check(t, d.TLS)
+		})
+	}
+}
+
+func TestNewDialerSASL(t *testing.T) {
+	tests := []struct {
+		mechanism string
+		want      string
+		wantErr   bool
+	}{
+		{mechanism: "", want: ""},
+		{mechanism: "PLAIN", want: "PLAIN"},
+		{mechanism: "SCRAM-SHA-256", want: "SCRAM-SHA-256"},
+		{mechanism: "SCRAM-SHA-512", want: "SCRAM-SHA-512"},
+		{mechanism: "GSSAPI", wantErr: true},
+		{mechanism: "plain", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mechanism, func(t *testing.T) {
+			d, err := NewDialer(&config.Config{KafkaSASLMechanism: tt.mechanism, KafkaSASLUsername: "user", KafkaSASLPassword: "secret"})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("NewDialer принял механизм %q", tt.mechanism)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewDialer: %v", err)
+			}
+			if tt.want == "" {
+				if d.SASLMechanism != nil {
+					t.Fatalf("SASL включён без KAFKA_SASL_MECHANISM: %v", d.SASLMechanism.Name())
+				}
+				return
+			}
+			if d.SASLMechanism == nil || d.SASLMechanism.Name() != tt.want {
+				t.Fatalf("механизм %v, ожидался %s", d.SASLMechanism, tt.want)
+			}
+			if p, ok := d.SASLMechanism.(plain.Mechanism); ok && (p.Username != "user" || p.Password != "secret") {
+				t.Errorf("учётные данные PLAIN: %+v", p)
+			}
+		})
+	}
+}
+
+func TestStartOffset(t *testing.T) {
+	if startOffset("last") != kafka.LastOffset || startOffset("first") != kafka.FirstOffset {
+		t.Fatal("startOffset не различает first и last")
+	}
+}
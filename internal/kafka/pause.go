@@ -0,0 +1,145 @@
+package kafka
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Причины приостановки consumer. Каждая снимается отдельно: ручное возобновление
+// не отменяет паузу, выставленную circuit breaker, и наоборот.
+const (
+	PauseAdmin   = "admin"
+	PauseBreaker = "circuit_breaker"
+)
+
+type PauseState struct {
+	Paused  bool       `json:"paused"`
+	Reasons []string   `json:"reasons"`
+	Since   *time.Time `json:"since,omitempty"`
+}
+
+// Pause приостанавливает чтение из Kafka. Сообщение, которое уже обрабатывается,
+// будет дообработано; следующее полученное сообщение ждёт возобновления и не
+// коммитится, так что смещения группы не теряются. Возвращает false, если пауза
+// по этой причине уже выставлена.
+func (c *Consumer) Pause(reason string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.paused[reason]; ok {
+		return false
+	}
+	if len(c.paused) == 0 {
+		c.resumed = make(chan struct{})
+	}
+	c.paused[reason] = time.Now().UTC()
+	c.metrics.ConsumerPaused.WithLabelValues(reason).Set(1)
+	c.logger.Warn("Чтение из Kafka приостановлено", zap.String("reason", reason))
+	return true
+}
+
+// Resume снимает паузу, выставленную по reason. Возвращает false, если такой паузы нет.
+func (c *Consumer) Resume(reason string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.paused[reason]; !ok {
+		return false
+	}
+	delete(c.paused, reason)
+	c.metrics.ConsumerPaused.WithLabelValues(reason).Set(0)
+	if len(c.paused) == 0 {
+		close(c.resumed)
+		c.logger.Info("Чтение из Kafka возобновлено", zap.String("reason", reason))
+	} else {
+		c.logger.Info("Снята одна из причин паузы, чтение из Kafka остаётся приостановленным",
+			zap.String("reason", reason), zap.Strings("remaining", c.reasonsLocked()))
+	}
+	return true
+}
+
+func (c *Consumer) PauseState() PauseState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	st := PauseState{Paused: len(c.paused) > 0, Reasons: c.reasonsLocked()}
+	for _, since := range c.paused {
+		if st.Since == nil || since.Before(*st.Since) {
+			st.Since = &since
+		}
+	}
+	return st
+}
+
+func (c *Consumer) reasonsLocked() []string {
+	reasons := make([]string, 0, len(c.paused))
+	for r := range c.paused {
+		reasons = append(reasons, r)
+	}
+	sort.Strings(reasons)
+	return reasons
+}
+
+// waitResumed блокируется, пока consumer приостановлен. Возвращает false, если
+// consumer остановлен раньше.
+func (c *Consumer) waitResumed(ctx context.Context) bool {
+	c.mu.Lock()
+	resumed := c.resumed
+	paused := len(c.paused) > 0
+	c.mu.Unlock()
+	if !paused {
+		return true
+	}
+
+	select {
+	case <-resumed:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-c.stopChan:
+		return false
+	}
+}
+
+// WatchStorage — circuit breaker перед хранилищем: после failures неудачных
+// проверок check подряд приостанавливает чтение из Kafka, а после первой
+// успешной возобновляет. Блокируется до отмены ctx.
+func (c *Consumer) WatchStorage(ctx context.Context, check func(context.Context) error, interval time.Duration, failures int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failed := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, interval)
+		err := check(checkCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err == nil {
+			failed = 0
+			c.Resume(PauseBreaker)
+			continue
+		}
+		failed++
+		c.logger.Warn("Проверка хранилища не прошла", zap.Int("failures", failed), zap.Error(err))
+		if failed == failures {
+			c.Pause(PauseBreaker)
+		}
+	}
+}
+
+func pauseReasons(st PauseState) string {
+	return strings.Join(st.Reasons, ", ")
+}
@@ -0,0 +1,218 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"order-app/internal/health"
+	"order-app/internal/repository"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestPauseResumeByReason(t *testing.T) {
+	c := newTestConsumer(t, repository.NewMemoryOrderRepository())
+	paused := func(reason string) float64 {
+		return testutil.ToFloat64(c.metrics.ConsumerPaused.WithLabelValues(reason))
+	}
+
+	if !c.Pause(PauseAdmin) || c.Pause(PauseAdmin) {
+		t.Fatal("повторная пауза по той же причине должна возвращать false")
+	}
+	if !c.Pause(PauseBreaker) {
+		t.Fatal("пауза по второй причине не выставлена")
+	}
+	if st := c.PauseState(); !st.Paused || !slices.Equal(st.Reasons, []string{PauseAdmin, PauseBreaker}) || st.Since == nil {
+		t.Fatalf("состояние %+v", st)
+	}
+	if paused(PauseAdmin) != 1 || paused(PauseBreaker) != 1 {
+		t.Errorf("метрика паузы: admin=%v, breaker=%v", paused(PauseAdmin), paused(PauseBreaker))
+	}
+
+	// Ручное возобновление не снимает паузу circuit breaker.
+	if !c.Resume(PauseAdmin) || c.Resume(PauseAdmin) {
+		t.Fatal("повторное возобновление по той же причине должно возвращать false")
+	}
+	if st := c.PauseState(); !st.Paused || !slices.Equal(st.Reasons, []string{PauseBreaker}) {
+		t.Fatalf("после снятия admin: %+v", st)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if c.waitResumed(ctx) {
+		t.Fatal("waitResumed вернулся, пока пауза breaker не снята")
+	}
+
+	resumed := make(chan bool)
+	go func() { resumed <- c.waitResumed(context.Background()) }()
+	c.Resume(PauseBreaker)
+	select {
+	case ok := <-resumed:
+		if !ok {
+			t.Fatal("waitResumed вернул false после возобновления")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waitResumed не вернулся после снятия всех пауз")
+	}
+	if st := c.PauseState(); st.Paused || len(st.Reasons) != 0 {
+		t.Fatalf("после снятия всех пауз: %+v", st)
+	}
+	if paused(PauseAdmin) != 0 || paused(PauseBreaker) != 0 {
+		t.Errorf("метрика паузы после возобновления: admin=%v, breaker=%v", paused(PauseAdmin), paused(PauseBreaker))
+	}
+
+	// После полного возобновления пауза снова блокирует чтение.
+	c.Pause(PauseAdmin)
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if c.waitResumed(ctx) {
+		t.Fatal("повторная пауза не блокирует чтение")
+	}
+}
+
+// fakePinger — проверка хранилища, результат которой задаёт тест.
+type fakePinger struct {
+	failing atomic.Bool
+	calls   atomic.Int32
+}
+
+func (p *fakePinger) Ping(context.Context) error {
+	p.calls.Add(1)
+	if p.failing.Load() {
+		return errors.New("хранилище недоступно")
+	}
+	return nil
+}
+
+func TestWatchStorage(t *testing.T) {
+	c := newTestConsumer(t, repository.NewMemoryOrderRepository())
+	pinger := &fakePinger{}
+	pinger.failing.Store(true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.WatchStorage(ctx, pinger.Ping, 5*time.Millisecond, 3)
+		close(done)
+	}()
+
+	waitFor(t, func() bool { return c.PauseState().Paused })
+	if calls := pinger.calls.Load(); calls < 3 {
+		t.Fatalf("пауза после %d неудачных проверок, ожидалось не меньше 3", calls)
+	}
+	if st := c.PauseState(); !slices.Equal(st.Reasons, []string{PauseBreaker}) {
+		t.Fatalf("причины паузы %v", st.Reasons)
+	}
+
+	// Пауза администратора переживает восстановление хранилища.
+	c.Pause(PauseAdmin)
+	pinger.failing.Store(false)
+	waitFor(t, func() bool { return !slices.Contains(c.PauseState().Reasons, PauseBreaker) })
+	if st := c.PauseState(); !st.Paused || !slices.Equal(st.Reasons, []string{PauseAdmin}) {
+		t.Fatalf("после восстановления хранилища: %+v", st)
+	}
+	c.Resume(PauseAdmin)
+
+	// После восстановления circuit breaker срабатывает снова.
+	pinger.failing.Store(true)
+	waitFor(t, func() bool { return c.PauseState().Paused })
+	pinger.failing.Store(false)
+	waitFor(t, func() bool { return !c.PauseState().Paused })
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("WatchStorage не завершился после отмены ctx")
+	}
+}
+
+func TestWatchStorageNeedsConsecutiveFailures(t *testing.T) {
+	c := newTestConsumer(t, repository.NewMemoryOrderRepository())
+	var calls atomic.Int32
+	// Каждая вторая проверка успешна: трёх неудач подряд не бывает.
+	check := func(context.Context) error {
+		if calls.Add(1)%2 == 0 {
+			return nil
+		}
+		return errors.New("хранилище недоступно")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.WatchStorage(ctx, check, time.Millisecond, 3)
+
+	waitFor(t, func() bool { return calls.Load() >= 20 })
+	if c.PauseState().Paused {
+		t.Fatal("circuit breaker сработал без трёх неудач подряд")
+	}
+}
+
+func TestHealthCheckPause(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	c := newTestConsumer(t, repository.NewMemoryOrderRepository())
+	c.brokers = []string{lis.Addr().String()}
+	checker := health.NewChecker(time.Second)
+	checker.Register("kafka", c.HealthCheck)
+	status := func() (string, bool) {
+		report := checker.Readiness(context.Background())
+		return report.Checks["kafka"].Status, report.Ready
+	}
+
+	tests := []struct {
+		name   string
+		pause  []string
+		status string
+		ready  bool
+	}{
+		{name: "running", status: "ok", ready: true},
+		{name: "admin pause", pause: []string{PauseAdmin}, status: "degraded", ready: true},
+		{name: "breaker pause", pause: []string{PauseBreaker}, status: "error", ready: false},
+		{name: "both", pause: []string{PauseAdmin, PauseBreaker}, status: "error", ready: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, reason := range tt.pause {
+				c.Pause(reason)
+				defer c.Resume(reason)
+			}
+			if got, ready := status(); got != tt.status || ready != tt.ready {
+				t.Fatalf("статус %s, готов %v; ожидалось %s, %v", got, ready, tt.status, tt.ready)
+			}
+		})
+	}
+
+	lis.Close()
+	if got, ready := status(); got != "error" || ready {
+		t.Fatalf("без брокеров: статус %s, готов %v", got, ready)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("условие не выполнилось за 5 с")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
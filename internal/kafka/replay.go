@@ -0,0 +1,485 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"order-app/config"
+	"order-app/internal/repository"
+	"order-app/internal/service"
+	"order-app/internal/tracing"
+	"order-app/internal/validation"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+var ErrReplayRunning = errors.New("перечитывание топика уже выполняется")
+
+// Временная ошибка хранилища при перечитывании повторяется с растущей задержкой,
+// прежде чем сообщение будет засчитано как failed; постоянная засчитывается сразу.
+const (
+	replayRetryAttempts  = 5
+	replayRetryBaseDelay = 500 * time.Millisecond
+)
+
+// Последние смещения диапазона могут не содержать сообщений: там бывают маркеры
+// транзакций или записи, удалённые компакцией. Читатель тогда ждёт бесконечно,
+// поэтому каждое чтение ограничено replayIdleTimeout; он заметно больше
+// replayMaxWait, за который брокер отвечает на fetch даже без новых данных.
+const (
+	replayMaxWait     = time.Second
+	replayIdleTimeout = 10 * time.Second
+)
+
+// partitionReader — часть kafka.Reader, нужная для перечитывания партиции.
+type partitionReader interface {
+	SetOffset(offset int64) error
+	ReadMessage(ctx context.Context) (kafka.Message, error)
+	Close() error
+}
+
+// ReplayOptions задаёт начало перечитывания: либо момент времени From, либо
+// явные смещения Offsets по партициям (партиции без смещения пропускаются).
+type ReplayOptions struct {
+	From    *time.Time    `json:"from,omitempty"`
+	Offsets map[int]int64 `json:"offsets,omitempty"`
+}
+
+func (o ReplayOptions) Validate() error {
+	if (o.From == nil) == (len(o.Offsets) == 0) {
+		return errors.New("нужно указать либо момент времени, либо смещения по партициям")
+	}
+	for p, off := range o.Offsets {
+		if p < 0 || off < 0 {
+			return fmt.Errorf("некорректное смещение %d для партиции %d", off, p)
+		}
+	}
+	return nil
+}
+
+type PartitionProgress struct {
+	Partition int   `json:"partition"`
+	Start     int64 `json:"start"`
+	End       int64 `json:"end"`
+	Current   int64 `json:"current"`
+	Processed int   `json:"processed"`
+	Invalid   int   `json:"invalid"`
+	Failed    int   `json:"failed"`
+}
+
+type ReplayStatus struct {
+	Running    bool                `json:"running"`
+	Topic      string              `json:"topic"`
+	Options    *ReplayOptions      `json:"options,omitempty"`
+	StartedAt  *time.Time          `json:"started_at,omitempty"`
+	FinishedAt *time.Time          `json:"finished_at,omitempty"`
+	Error      string              `json:"error,omitempty"`
+	Partitions []PartitionProgress `json:"partitions"`
+}
+
+// Replayer перечитывает топик отдельными читателями без consumer group, так что
+// смещения основной группы не меняются. Читается диапазон до конца партиции на
+// момент старта; заказы сохраняются через OrderService.ProcessOrder, а повторная
+// вставка уже сохранённого заказа ничего не меняет.
+type Replayer struct {
+	dialer  *kafka.Dialer
+	brokers []string
+	topic   string
+	orders  *validation.OrderValidator
+	svc     *service.OrderService
+	logger  *zap.Logger
+
+	// newReader и highWaterMark подменяются в тестах.
+	newReader     func(partition int) partitionReader
+	highWaterMark func(ctx context.Context, partition int) (int64, error)
+	idleTimeout   time.Duration
+
+	mu     sync.Mutex
+	status ReplayStatus
+	cancel context.CancelFunc
+}
+
+func NewReplayer(cfg *config.Config, svc *service.OrderService, log *zap.Logger) (*Replayer, error) {
+	orders, err := validation.NewOrderValidator()
+	if err != nil {
+		return nil, err
+	}
+	dialer, err := NewDialer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	r := &Replayer{
+		dialer:      dialer,
+		brokers:     cfg.KafkaBrokers,
+		topic:       cfg.KafkaTopic,
+		orders:      orders,
+		svc:         svc,
+		logger:      log,
+		idleTimeout: replayIdleTimeout,
+		status:      ReplayStatus{Topic: cfg.KafkaTopic, Partitions: []PartitionProgress{}},
+	}
+	r.newReader = r.openReader
+	r.highWaterMark = r.readHighWaterMark
+	return r, nil
+}
+
+// Start запускает перечитывание в фоне и сразу возвращается.
+func (r *Replayer) Start(opts ReplayOptions) error {
+	ctx, err := r.begin(opts)
+	if err != nil {
+		return err
+	}
+	go r.run(ctx, opts)
+	return nil
+}
+
+// Run перечитывает топик и возвращает итоговый статус; progress вызывается
+// с текущим статусом раз в period, пока идёт перечитывание.
+func (r *Replayer) Run(ctx context.Context, opts ReplayOptions, period time.Duration, progress func(ReplayStatus)) (ReplayStatus, error) {
+	runCtx, err := r.begin(opts)
+	if err != nil {
+		return ReplayStatus{}, err
+	}
+	stop := context.AfterFunc(ctx, r.Cancel)
+	defer stop()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				progress(r.Status())
+			}
+		}
+	}()
+
+	err = r.run(runCtx, opts)
+	close(done)
+	return r.Status(), err
+}
+
+func (r *Replayer) Status() ReplayStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := r.status
+	st.Partitions = append([]PartitionProgress{}, r.status.Partitions...)
+	return st
+}
+
+func (r *Replayer) Cancel() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		r.cancel()
+	}
+}
+
+func (r *Replayer) begin(opts ReplayOptions) (context.Context, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.status.Running {
+		return nil, ErrReplayRunning
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.status = ReplayStatus{
+		Running:    true,
+		Topic:      r.topic,
+		Options:    &opts,
+		StartedAt:  timePtr(time.Now().UTC()),
+		Partitions: []PartitionProgress{},
+	}
+	return ctx, nil
+}
+
+func (r *Replayer) run(ctx context.Context, opts ReplayOptions) (err error) {
+	defer func() {
+		r.mu.Lock()
+		r.cancel()
+		r.status.Running = false
+		r.status.FinishedAt = timePtr(time.Now().UTC())
+		if err != nil {
+			r.status.Error = err.Error()
+		}
+		st := r.status
+		r.mu.Unlock()
+
+		processed, invalid, failed := 0, 0, 0
+		for _, p := range st.Partitions {
+			processed += p.Processed
+			invalid += p.Invalid
+			failed += p.Failed
+		}
+		log := r.logger.With(zap.String("topic", r.topic), zap.Int("processed", processed),
+			zap.Int("invalid", invalid), zap.Int("failed", failed))
+		if err != nil {
+			log.Error("Перечитывание топика прервано", zap.Error(err))
+		} else {
+			log.Info("Перечитывание топика завершено")
+		}
+	}()
+
+	ranges, err := r.plan(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.status.Partitions = ranges
+	r.mu.Unlock()
+	r.logger.Info("Начато перечитывание топика", zap.String("topic", r.topic), zap.Any("partitions", ranges))
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(ranges))
+	for i := range ranges {
+		if ranges[i].Start >= ranges[i].End {
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = r.replayPartition(ctx, i, ranges[i])
+		}(i)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	failed := 0
+	for _, p := range r.status.Partitions {
+		failed += p.Failed
+	}
+	r.mu.Unlock()
+	if failed > 0 {
+		return fmt.Errorf("не удалось сохранить %d заказов", failed)
+	}
+	return nil
+}
+
+// plan определяет для каждой партиции диапазон [Start, End) смещений.
+func (r *Replayer) plan(ctx context.Context, opts ReplayOptions) ([]PartitionProgress, error) {
+	partitions, err := r.partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ranges []PartitionProgress
+	for _, p := range partitions {
+		start, requested := opts.Offsets[p]
+		if opts.From == nil && !requested {
+			continue
+		}
+
+		conn, err := r.dialLeader(ctx, p)
+		if err != nil {
+			return nil, fmt.Errorf("партиция %d: не удалось подключиться к лидеру: %w", p, err)
+		}
+		first, end, err := conn.ReadOffsets()
+		if err == nil && opts.From != nil {
+			start, err = conn.ReadOffset(*opts.From)
+		}
+		conn.Close()
+		if err != nil {
+			return nil, fmt.Errorf("партиция %d: не удалось прочитать смещения: %w", p, err)
+		}
+
+		// Для времени позже последнего сообщения Kafka возвращает -1 или конец партиции.
+		if start < 0 || start > end {
+			start = end
+		}
+		start = max(start, first)
+		ranges = append(ranges, PartitionProgress{Partition: p, Start: start, End: end, Current: start})
+	}
+
+	for p := range opts.Offsets {
+		if !containsPartition(partitions, p) {
+			return nil, fmt.Errorf("в топике %s нет партиции %d", r.topic, p)
+		}
+	}
+	return ranges, nil
+}
+
+func (r *Replayer) partitions(ctx context.Context) ([]int, error) {
+	var lastErr error
+	for _, broker := range r.brokers {
+		conn, err := r.dialer.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		parts, err := conn.ReadPartitions(r.topic)
+		conn.Close()
+		if err != nil {
+			return nil, fmt.Errorf("не удалось получить партиции топика %s: %w", r.topic, err)
+		}
+
+		ids := make([]int, 0, len(parts))
+		for _, p := range parts {
+			ids = append(ids, p.ID)
+		}
+		sort.Ints(ids)
+		return ids, nil
+	}
+	return nil, fmt.Errorf("брокеры Kafka недоступны: %w", lastErr)
+}
+
+func (r *Replayer) dialLeader(ctx context.Context, partition int) (conn *kafka.Conn, err error) {
+	for _, broker := range r.brokers {
+		if conn, err = r.dialer.DialLeader(ctx, "tcp", broker, r.topic, partition); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+func (r *Replayer) openReader(partition int) partitionReader {
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   r.brokers,
+		Topic:     r.topic,
+		Partition: partition,
+		Dialer:    r.dialer,
+		MinBytes:  1,
+		MaxBytes:  10e6, // 10MB
+		MaxWait:   replayMaxWait,
+	})
+}
+
+func (r *Replayer) readHighWaterMark(ctx context.Context, partition int) (int64, error) {
+	conn, err := r.dialLeader(ctx, partition)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	return conn.ReadLastOffset()
+}
+
+// replayPartition читает партицию до pr.End. Раньше, чем дойдёт до End-1, он
+// останавливается, если следующее сообщение уже за End или если за idleTimeout
+// не пришло ничего, хотя брокер доступен и все смещения до End у него есть:
+// значит, в хвосте диапазона нет сообщений.
+func (r *Replayer) replayPartition(ctx context.Context, idx int, pr PartitionProgress) error {
+	reader := r.newReader(pr.Partition)
+	defer reader.Close()
+
+	if err := reader.SetOffset(pr.Start); err != nil {
+		return fmt.Errorf("партиция %d: %w", pr.Partition, err)
+	}
+
+	for offset := pr.Start; offset < pr.End; {
+		readCtx, cancel := context.WithTimeout(ctx, r.idleTimeout)
+		m, err := reader.ReadMessage(readCtx)
+		cancel()
+		if err != nil && errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			hwm, hwmErr := r.highWaterMark(ctx, pr.Partition)
+			if hwmErr != nil {
+				return fmt.Errorf("партиция %d, смещение %d: не удалось проверить конец партиции: %w", pr.Partition, offset, hwmErr)
+			}
+			if hwm < pr.End {
+				return fmt.Errorf("партиция %d: конец партиции сместился назад с %d до %d", pr.Partition, pr.End, hwm)
+			}
+			r.logger.Info("В конце диапазона нет сообщений, перечитывание партиции завершено",
+				zap.Int("partition", pr.Partition), zap.Int64("offset", offset), zap.Int64("end", pr.End))
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("партиция %d, смещение %d: %w", pr.Partition, offset, err)
+		}
+		if m.Offset >= pr.End {
+			break
+		}
+		offset = m.Offset + 1
+
+		result := r.process(ctx, m)
+		r.mu.Lock()
+		p := &r.status.Partitions[idx]
+		p.Current = offset
+		switch result {
+		case "invalid":
+			p.Invalid++
+		case "failed":
+			p.Failed++
+		default:
+			p.Processed++
+		}
+		r.mu.Unlock()
+	}
+
+	r.mu.Lock()
+	r.status.Partitions[idx].Current = pr.End
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Replayer) process(ctx context.Context, m kafka.Message) (result string) {
+	ctx, span := tracing.Start(ctx, "kafka.replay "+m.Topic,
+		attribute.Int("messaging.kafka.destination.partition", m.Partition),
+		attribute.Int64("messaging.kafka.message.offset", m.Offset),
+	)
+	var err error
+	defer func() { tracing.End(span, err) }()
+
+	order, err := r.orders.Parse(m.Value)
+	if err != nil {
+		r.logger.Warn("Пропущено некорректное сообщение при перечитывании",
+			zap.Int("partition", m.Partition), zap.Int64("offset", m.Offset), zap.Error(err))
+		return "invalid"
+	}
+	delay := replayRetryBaseDelay
+	for attempt := 1; ; attempt++ {
+		if _, err = r.svc.ProcessOrder(ctx, order); err == nil {
+			return "processed"
+		}
+		if attempt == replayRetryAttempts || !repository.IsTransient(err) || !sleep(ctx, delay) {
+			break
+		}
+		r.logger.Warn("Не удалось сохранить заказ при перечитывании, повторим",
+			zap.Int("partition", m.Partition), zap.Int64("offset", m.Offset),
+			zap.Int("attempt", attempt), zap.Duration("delay", delay), zap.Error(err))
+		delay *= 2
+	}
+	r.logger.Error("Не удалось обработать заказ при перечитывании",
+		zap.Int("partition", m.Partition), zap.Int64("offset", m.Offset), zap.Error(err))
+	return "failed"
+}
+
+// sleep ждёт d и возвращает false, если ctx отменён раньше.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+func containsPartition(partitions []int, p int) bool {
+	for _, id := range partitions {
+		if id == p {
+			return true
+		}
+	}
+	return false
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,176 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"order-app/internal/cache"
+	"order-app/internal/domain"
+	"order-app/internal/repository"
+	"order-app/internal/repository/storetest"
+	"order-app/internal/service"
+	"order-app/internal/validation"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// errConnLost — ошибка, с которой pgx теряет соединение с базой.
+var errConnLost = &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+
+// flakyStore отказывает в сохранении первые failures раз, имитируя временный
+// сбой базы данных.
+type flakyStore struct {
+	*repository.MemoryOrderRepository
+	failures atomic.Int32
+	calls    atomic.Int32
+}
+
+func (s *flakyStore) SaveOrder(ctx context.Context, order *domain.Order) (bool, error) {
+	s.calls.Add(1)
+	if s.failures.Add(-1) >= 0 {
+		return false, fmt.Errorf("не удалось вставить заказ в БД: %w", errConnLost)
+	}
+	return s.MemoryOrderRepository.SaveOrder(ctx, order)
+}
+
+func orderMessage(t *testing.T, uid string) kafka.Message {
+	t.Helper()
+	value, err := json.Marshal(storetest.NewOrder(uid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kafka.Message{Topic: "orders", Partition: 0, Offset: 1, Value: value}
+}
+
+func newTestReplayer(t *testing.T, store repository.OrderStore) *Replayer {
+	t.Helper()
+	orders, err := validation.NewOrderValidator()
+	if err != nil {
+		t.Fatalf("NewOrderValidator: %v", err)
+	}
+	svc := service.NewOrderService(store, cache.NewCache(time.Minute), zap.NewNop())
+	return &Replayer{topic: "orders", orders: orders, svc: svc, logger: zap.NewNop()}
+}
+
+func TestReplayRetriesStorageError(t *testing.T) {
+	store := &flakyStore{MemoryOrderRepository: repository.NewMemoryOrderRepository()}
+	store.failures.Store(1)
+	r := newTestReplayer(t, store)
+
+	if got := r.process(context.Background(), orderMessage(t, "uid-replay-retry")); got != "processed" {
+		t.Fatalf("process = %q, ожидалось processed после повтора", got)
+	}
+	if got := store.calls.Load(); got != 2 {
+		t.Fatalf("SaveOrder вызван %d раз, ожидалось 2", got)
+	}
+}
+
+func TestReplayFailsWhenCancelled(t *testing.T) {
+	store := &flakyStore{MemoryOrderRepository: repository.NewMemoryOrderRepository()}
+	store.failures.Store(replayRetryAttempts)
+	r := newTestReplayer(t, store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := r.process(ctx, orderMessage(t, "uid-replay-cancel")); got != "failed" {
+		t.Fatalf("process = %q, ожидалось failed", got)
+	}
+	if got := store.calls.Load(); got != 1 {
+		t.Fatalf("после отмены SaveOrder не должен повторяться, вызван %d раз", got)
+	}
+}
+
+// fakePartitionReader отдаёт сообщения по очереди, а затем ждёт, как kafka.Reader
+// на партиции без новых сообщений.
+type fakePartitionReader struct {
+	msgs  []kafka.Message
+	reads int
+}
+
+func (f *fakePartitionReader) SetOffset(int64) error { return nil }
+
+func (f *fakePartitionReader) ReadMessage(ctx context.Context) (kafka.Message, error) {
+	if f.reads < len(f.msgs) {
+		f.reads++
+		return f.msgs[f.reads-1], nil
+	}
+	<-ctx.Done()
+	return kafka.Message{}, ctx.Err()
+}
+
+func (f *fakePartitionReader) Close() error { return nil }
+
+func TestReplayPartitionEnd(t *testing.T) {
+	hwmErr := errors.New("брокер недоступен")
+	tests := []struct {
+		name      string
+		offsets   []int64
+		hwm       int64
+		hwmErr    error
+		processed int
+		reads     int
+		hwmCalls  int
+		wantErr   error
+	}{
+		{name: "last offset present", offsets: []int64{0, 1, 2, 3, 4, 5}, processed: 5, reads: 5},
+		{name: "last offset missing", offsets: []int64{0, 1, 2, 3}, hwm: 5, processed: 4, reads: 4, hwmCalls: 1},
+		{name: "last offset missing, newer message", offsets: []int64{0, 1, 2, 6}, processed: 3, reads: 4},
+		{name: "compacted range", offsets: []int64{0, 3}, hwm: 7, processed: 2, reads: 2, hwmCalls: 1},
+		{name: "broker unavailable", offsets: []int64{0, 1}, hwmErr: hwmErr, processed: 2, reads: 2, hwmCalls: 1, wantErr: hwmErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := &fakePartitionReader{}
+			for i, off := range tt.offsets {
+				m := orderMessage(t, fmt.Sprintf("uid-replay-end-%d", i))
+				m.Offset = off
+				reader.msgs = append(reader.msgs, m)
+			}
+			hwmCalls := 0
+			r := newTestReplayer(t, repository.NewMemoryOrderRepository())
+			r.newReader = func(int) partitionReader { return reader }
+			r.highWaterMark = func(context.Context, int) (int64, error) {
+				hwmCalls++
+				return tt.hwm, tt.hwmErr
+			}
+			r.idleTimeout = 50 * time.Millisecond
+
+			pr := PartitionProgress{Start: 0, End: 5}
+			r.status.Partitions = []PartitionProgress{pr}
+			err := r.replayPartition(context.Background(), 0, pr)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("replayPartition: %v, ожидалось %v", err, tt.wantErr)
+			}
+
+			got := r.Status().Partitions[0]
+			if got.Processed != tt.processed || reader.reads != tt.reads || hwmCalls != tt.hwmCalls {
+				t.Errorf("обработано %d, прочитано %d, проверок конца %d; ожидалось %d, %d, %d",
+					got.Processed, reader.reads, hwmCalls, tt.processed, tt.reads, tt.hwmCalls)
+			}
+			if tt.wantErr == nil && got.Current != pr.End {
+				t.Errorf("Current = %d, ожидалось %d", got.Current, pr.End)
+			}
+		})
+	}
+}
+
+func TestReplayDoesNotRetryPermanentError(t *testing.T) {
+	store := &failingStore{MemoryOrderRepository: repository.NewMemoryOrderRepository(), err: errInvalidData}
+	r := newTestReplayer(t, store)
+
+	if got := r.process(context.Background(), orderMessage(t, "uid-replay-permanent")); got != "failed" {
+		t.Fatalf("process = %q, ожидалось failed", got)
+	}
+	if got := store.calls.Load(); got != 1 {
+		t.Fatalf("постоянная ошибка не должна повторяться, SaveOrder вызван %d раз", got)
+	}
+}
@@ -1,12 +1,77 @@
 package logger
 
 import (
+	"errors"
+	"net/url"
+	"sync"
+
 	"order-app/config"
+	"order-app/internal/redact"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+var registerOnce sync.Once
+
+func NewZapLogger(cfg *config.Config) (*zap.Logger, zap.AtomicLevel, error) {
+	var registerErr error
+	registerOnce.Do(func() {
+		registerErr = errors.Join(
+			zap.RegisterEncoder("redacted-json", func(ec zapcore.EncoderConfig) (zapcore.Encoder, error) {
+				return redact.NewEncoder(zapcore.NewJSONEncoder(ec)), nil
+			}),
+			zap.RegisterEncoder("redacted-console", func(ec zapcore.EncoderConfig) (zapcore.Encoder, error) {
+				return redact.NewEncoder(zapcore.NewConsoleEncoder(ec)), nil
+			}),
+			zap.RegisterSink("rotate", func(u *url.URL) (zap.Sink, error) {
+				return rotatingSinkFor(cfg, u.Host+u.Path), nil
+			}),
+		)
+	})
+	if registerErr != nil {
+		return nil, zap.AtomicLevel{}, registerErr
+	}
+
+	zapCfg := config.NewZapLoggerConfig(cfg)
+	l, err := zapCfg.Build()
+	if err != nil {
+		return nil, zap.AtomicLevel{}, err
+	}
+	return l, zapCfg.Level, nil
+}
+
+var (
+	rotatingMu    sync.Mutex
+	rotatingSinks = map[string]*rotatingSink{}
 )
 
-func NewZapLogger() (*zap.Logger, error) {
-	zapCfg := config.NewZapLoggerConfig()
-	return zapCfg.Build()
+// rotatingSinkFor возвращает один lumberjack.Logger на файл. Файл указан и в
+// OutputPaths, и в ErrorOutputPaths, и два независимых логгера ротировали бы его
+// одновременно, затирая записи друг друга.
+func rotatingSinkFor(cfg *config.Config, filename string) *rotatingSink {
+	rotatingMu.Lock()
+	defer rotatingMu.Unlock()
+
+	if sink, ok := rotatingSinks[filename]; ok {
+		return sink
+	}
+	sink := &rotatingSink{Logger: &lumberjack.Logger{
+		Filename:   filename,
+		MaxSize:    int(cfg.LogFileMaxSizeMB),
+		MaxBackups: int(cfg.LogFileMaxBackups),
+		MaxAge:     int(cfg.LogFileMaxAgeDays),
+		Compress:   cfg.LogFileCompress,
+	}}
+	rotatingSinks[filename] = sink
+	return sink
+}
+
+type rotatingSink struct {
+	*lumberjack.Logger
+}
+
+func (s *rotatingSink) Sync() error {
+	return nil
 }
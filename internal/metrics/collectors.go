@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"order-app/internal/cache"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type cacheCollector struct {
+	cache     *cache.OrderCache
+	size      *prometheus.Desc
+	hits      *prometheus.Desc
+	misses    *prometheus.Desc
+	evictions *prometheus.Desc
+}
+
+func NewCacheCollector(c *cache.OrderCache) prometheus.Collector {
+	return &cacheCollector{
+		cache:     c,
+		size:      prometheus.NewDesc(namespace+"_cache_size", "Количество заказов в кэше.", nil, nil),
+		hits:      prometheus.NewDesc(namespace+"_cache_hits_total", "Попадания в кэш.", nil, nil),
+		misses:    prometheus.NewDesc(namespace+"_cache_misses_total", "Промахи кэша.", nil, nil),
+		evictions: prometheus.NewDesc(namespace+"_cache_evictions_total", "Записи, удалённые из кэша по TTL.", nil, nil),
+	}
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.size
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.evictions
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	st := c.cache.Stats()
+	ch <- prometheus.MustNewConstMetric(c.size, prometheus.GaugeValue, float64(st.Size))
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(st.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(st.Misses))
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(st.Evictions))
+}
+
+type poolCollector struct {
+	pools         map[string]*pgxpool.Pool
+	total         *prometheus.Desc
+	acquired      *prometheus.Desc
+	idle          *prometheus.Desc
+	maxConns      *prometheus.Desc
+	acquires      *prometheus.Desc
+	emptyAcquires *prometheus.Desc
+	waitDuration  *prometheus.Desc
+}
+
+func NewPoolCollector(pools map[string]*pgxpool.Pool) prometheus.Collector {
+	labels := []string{"pool"}
+	return &poolCollector{
+		pools:         pools,
+		total:         prometheus.NewDesc(namespace+"_db_pool_total_conns", "Всего соединений в пуле.", labels, nil),
+		acquired:      prometheus.NewDesc(namespace+"_db_pool_acquired_conns", "Соединения, занятые в данный момент.", labels, nil),
+		idle:          prometheus.NewDesc(namespace+"_db_pool_idle_conns", "Свободные соединения.", labels, nil),
+		maxConns:      prometheus.NewDesc(namespace+"_db_pool_max_conns", "Максимальный размер пула.", labels, nil),
+		acquires:      prometheus.NewDesc(namespace+"_db_pool_acquires_total", "Успешные получения соединения из пула.", labels, nil),
+		emptyAcquires: prometheus.NewDesc(namespace+"_db_pool_empty_acquires_total", "Получения соединения, которым пришлось ждать.", labels, nil),
+		waitDuration:  prometheus.NewDesc(namespace+"_db_pool_acquire_wait_seconds_total", "Суммарное время ожидания соединения.", labels, nil),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.total
+	ch <- c.acquired
+	ch <- c.idle
+	ch <- c.maxConns
+	ch <- c.acquires
+	ch <- c.emptyAcquires
+	ch <- c.waitDuration
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	for name, pool := range c.pools {
+		st := pool.Stat()
+		ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(st.TotalConns()), name)
+		ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(st.AcquiredConns()), name)
+		ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(st.IdleConns()), name)
+		ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(st.MaxConns()), name)
+		ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(st.AcquireCount()), name)
+		ch <- prometheus.MustNewConstMetric(c.emptyAcquires, prometheus.CounterValue, float64(st.EmptyAcquireCount()), name)
+		ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, st.AcquireDuration().Seconds(), name)
+	}
+}
+
+// NewSubscribersGauge отдаёт число активных подписок на поток заказов (SSE и gRPC WatchOrders).
+func NewSubscribersGauge(count func() int) prometheus.Collector {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "stream",
+		Name:      "subscribers",
+		Help:      "Количество активных подписок на поток новых заказов.",
+	}, func() float64 { return float64(count()) })
+}
@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "orders"
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	MessagesConsumed  *prometheus.CounterVec
+	MessagesProcessed *prometheus.CounterVec
+	ProcessingLatency *prometheus.HistogramVec
+	ConsumerLag       *prometheus.GaugeVec
+	ConsumerPaused    *prometheus.GaugeVec
+
+	HTTPRequests *prometheus.CounterVec
+	HTTPLatency  *prometheus.HistogramVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		MessagesConsumed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "kafka",
+			Name:      "messages_consumed_total",
+			Help:      "Количество прочитанных из Kafka сообщений.",
+		}, []string{"topic", "partition"}),
+		MessagesProcessed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "kafka",
+			Name:      "messages_processed_total",
+			Help:      "Сообщения по итогам валидации (valid, invalid), неудачные попытки сохранения (failed) и пропущенные из-за постоянной ошибки хранилища (dropped).",
+		}, []string{"topic", "partition", "result"}),
+		ProcessingLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "kafka",
+			Name:      "processing_duration_seconds",
+			Help:      "Время обработки одного сообщения от валидации до сохранения.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"topic"}),
+		ConsumerLag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "kafka",
+			Name:      "consumer_lag",
+			Help:      "Отставание consumer от конца партиции в сообщениях.",
+		}, []string{"topic", "partition"}),
+		ConsumerPaused: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "kafka",
+			Name:      "consumer_paused",
+			Help:      "1, если чтение из Kafka приостановлено по указанной причине (admin, circuit_breaker).",
+		}, []string{"reason"}),
+		HTTPRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Количество HTTP-запросов.",
+		}, []string{"method", "route", "status"}),
+		HTTPLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Время обработки HTTP-запросов.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.MessagesConsumed,
+		m.MessagesProcessed,
+		m.ProcessingLatency,
+		m.ConsumerLag,
+		m.ConsumerPaused,
+		m.HTTPRequests,
+		m.HTTPLatency,
+	)
+	return m
+}
+
+func (m *Metrics) MustRegister(cs ...prometheus.Collector) {
+	m.registry.MustRegister(cs...)
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+func (m *Metrics) GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		m.HTTPRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		m.HTTPLatency.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
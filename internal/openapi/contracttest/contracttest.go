@@ -0,0 +1,97 @@
+package contracttest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"order-app/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Case struct {
+	Name   string
+	Method string
+	Path   string
+	// Route — шаблон маршрута в нотации gin, по которому ищется операция в спецификации.
+	Route  string
+	Header http.Header
+	Body   string
+	Status int
+}
+
+// Cases возвращает запросы, покрывающие основные ответы API. orderUID должен
+// существовать в хранилище, за которым стоит проверяемый engine.
+func Cases(orderUID string) []Case {
+	return []Case{
+		{Name: "order found", Method: http.MethodGet, Path: "/order/" + orderUID, Route: "/order/:id", Status: http.StatusOK},
+		{Name: "order masked", Method: http.MethodGet, Path: "/order/" + orderUID + "?view=masked", Route: "/order/:id", Status: http.StatusOK},
+		{Name: "order not found", Method: http.MethodGet, Path: "/order/missing", Route: "/order/:id", Status: http.StatusNotFound},
+		{Name: "batch", Method: http.MethodPost, Path: "/orders/batch", Route: "/orders/batch", Body: `{"order_uids":["` + orderUID + `","missing"]}`, Status: http.StatusOK},
+		{Name: "batch empty", Method: http.MethodPost, Path: "/orders/batch", Route: "/orders/batch", Body: `{"order_uids":[]}`, Status: http.StatusBadRequest},
+		{Name: "export ndjson", Method: http.MethodGet, Path: "/orders/export", Route: "/orders/export", Status: http.StatusOK},
+		{Name: "export csv gzip", Method: http.MethodGet, Path: "/orders/export?format=csv&compress=gzip", Route: "/orders/export", Status: http.StatusOK},
+		{Name: "export bad format", Method: http.MethodGet, Path: "/orders/export?format=xml", Route: "/orders/export", Status: http.StatusBadRequest},
+		{Name: "shard stats", Method: http.MethodGet, Path: "/stats/shards", Route: "/stats/shards"},
+		{Name: "liveness", Method: http.MethodGet, Path: "/healthz", Route: "/healthz", Status: http.StatusOK},
+		{Name: "readiness", Method: http.MethodGet, Path: "/readyz", Route: "/readyz"},
+		{Name: "log level", Method: http.MethodGet, Path: "/admin/log-level", Route: "/admin/log-level", Status: http.StatusOK},
+		{Name: "set log level", Method: http.MethodPut, Path: "/admin/log-level", Route: "/admin/log-level", Body: `{"level":"info"}`, Status: http.StatusOK},
+		{Name: "replay status", Method: http.MethodGet, Path: "/admin/kafka/replay", Route: "/admin/kafka/replay", Status: http.StatusOK},
+		{Name: "replay bad options", Method: http.MethodPost, Path: "/admin/kafka/replay", Route: "/admin/kafka/replay", Body: `{}`, Status: http.StatusBadRequest},
+		{Name: "replay cancel", Method: http.MethodDelete, Path: "/admin/kafka/replay", Route: "/admin/kafka/replay", Status: http.StatusAccepted},
+		{Name: "consumer pause", Method: http.MethodPost, Path: "/admin/kafka/consumer/pause", Route: "/admin/kafka/consumer/pause", Status: http.StatusOK},
+		{Name: "consumer state", Method: http.MethodGet, Path: "/admin/kafka/consumer", Route: "/admin/kafka/consumer", Status: http.StatusOK},
+		{Name: "consumer resume", Method: http.MethodPost, Path: "/admin/kafka/consumer/resume", Route: "/admin/kafka/consumer/resume", Status: http.StatusOK},
+		{Name: "metrics", Method: http.MethodGet, Path: "/metrics", Route: "/metrics", Status: http.StatusOK},
+		{Name: "openapi", Method: http.MethodGet, Path: "/openapi.json", Route: "/openapi.json", Status: http.StatusOK},
+		{Name: "docs", Method: http.MethodGet, Path: "/docs", Route: "/docs", Status: http.StatusOK},
+		{Name: "ui", Method: http.MethodGet, Path: "/ui/", Route: "/ui/*filepath", Status: http.StatusOK},
+		{Name: "index", Method: http.MethodGet, Path: "/", Route: "/", Status: http.StatusFound},
+	}
+}
+
+// Run проверяет, что каждый маршрут engine описан в спецификации, затем выполняет
+// cases и сверяет статус, Content-Type и тело каждого ответа с документом.
+// Status == 0 означает, что допустим любой описанный статус.
+func Run(t *testing.T, engine *gin.Engine, cases []Case) {
+	t.Helper()
+
+	v, err := openapi.NewValidator()
+	if err != nil {
+		t.Fatalf("NewValidator: %v", err)
+	}
+
+	if missing := v.Undocumented(engine.Routes()); len(missing) > 0 {
+		t.Errorf("маршруты не описаны в спецификации: %s", strings.Join(missing, ", "))
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var body io.Reader
+			if tc.Body != "" {
+				body = strings.NewReader(tc.Body)
+			}
+			req := httptest.NewRequest(tc.Method, tc.Path, body)
+			for k, vals := range tc.Header {
+				req.Header[k] = vals
+			}
+			if tc.Body != "" && req.Header.Get("Content-Type") == "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if tc.Status != 0 && w.Code != tc.Status {
+				t.Fatalf("%s %s: статус %d, ожидался %d: %s", tc.Method, tc.Path, w.Code, tc.Status, w.Body.String())
+			}
+			if err := v.ValidateResponse(tc.Method, tc.Route, w.Code, w.Header(), w.Body.Bytes()); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
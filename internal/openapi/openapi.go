@@ -0,0 +1,212 @@
+package openapi
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+//go:embed openapi.json static
+var files embed.FS
+
+// Spec возвращает OpenAPI-документ сервиса.
+func Spec() []byte {
+	spec, err := files.ReadFile("openapi.json")
+	if err != nil {
+		panic(err)
+	}
+	return spec
+}
+
+// Register отдаёт спецификацию по /openapi.json и её просмотрщик по /docs.
+func Register(r *gin.Engine) {
+	spec := Spec()
+	viewer, err := files.ReadFile("static/docs.html")
+	if err != nil {
+		panic(err)
+	}
+
+	r.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", spec)
+	})
+	r.GET("/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", viewer)
+	})
+}
+
+type document struct {
+	Paths      map[string]map[string]operation `json:"paths"`
+	Components struct {
+		Responses map[string]response `json:"responses"`
+	} `json:"components"`
+	raw map[string]json.RawMessage
+}
+
+type operation struct {
+	Responses map[string]response `json:"responses"`
+}
+
+type response struct {
+	Ref     string                     `json:"$ref"`
+	Content map[string]json.RawMessage `json:"content"`
+}
+
+// Validator сверяет фактические ответы с документом.
+type Validator struct {
+	doc     document
+	mu      sync.Mutex
+	schemas map[string]*gojsonschema.Schema
+}
+
+func NewValidator() (*Validator, error) {
+	spec := Spec()
+	v := &Validator{schemas: make(map[string]*gojsonschema.Schema)}
+	if err := json.Unmarshal(spec, &v.doc); err != nil {
+		return nil, fmt.Errorf("не удалось разобрать спецификацию: %w", err)
+	}
+	if err := json.Unmarshal(spec, &v.doc.raw); err != nil {
+		return nil, fmt.Errorf("не удалось разобрать спецификацию: %w", err)
+	}
+	return v, nil
+}
+
+// Operations возвращает задокументированные операции в виде "METHOD /path/{param}".
+func (v *Validator) Operations() []string {
+	var ops []string
+	for path, item := range v.doc.Paths {
+		for method := range item {
+			ops = append(ops, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(ops)
+	return ops
+}
+
+// Undocumented возвращает маршруты gin, которых нет в документе. HEAD-маршруты,
+// которые gin регистрирует для статики, не учитываются.
+func (v *Validator) Undocumented(routes gin.RoutesInfo) []string {
+	var missing []string
+	for _, route := range routes {
+		if route.Method == http.MethodHead {
+			continue
+		}
+		if _, ok := v.doc.Paths[specPath(route.Path)][strings.ToLower(route.Method)]; !ok {
+			missing = append(missing, route.Method+" "+route.Path)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// ValidateResponse проверяет, что статус, тип содержимого и тело ответа на запрос
+// method к маршруту route (в нотации gin или OpenAPI) описаны в документе.
+func (v *Validator) ValidateResponse(method, route string, status int, header http.Header, body []byte) error {
+	path := specPath(route)
+	op, ok := v.doc.Paths[path][strings.ToLower(method)]
+	if !ok {
+		return fmt.Errorf("операция %s %s не описана в спецификации", method, path)
+	}
+
+	resp, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		if resp, ok = op.Responses["default"]; !ok {
+			return fmt.Errorf("%s %s: статус %d не описан в спецификации", method, path, status)
+		}
+	}
+	if ref := resp.Ref; ref != "" {
+		if resp, ok = v.doc.Components.Responses[strings.TrimPrefix(ref, "#/components/responses/")]; !ok {
+			return fmt.Errorf("%s %s: не найден ответ %s", method, path, ref)
+		}
+	}
+
+	if len(resp.Content) == 0 {
+		if len(bytes.TrimSpace(body)) > 0 {
+			return fmt.Errorf("%s %s %d: тело ответа не описано в спецификации", method, path, status)
+		}
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return fmt.Errorf("%s %s %d: некорректный Content-Type %q", method, path, status, header.Get("Content-Type"))
+	}
+	media, ok := resp.Content[mediaType]
+	if !ok {
+		return fmt.Errorf("%s %s %d: тип содержимого %s не описан в спецификации", method, path, status, mediaType)
+	}
+	if mediaType != "application/json" {
+		return nil
+	}
+
+	var m struct {
+		Schema json.RawMessage `json:"schema"`
+	}
+	if err := json.Unmarshal(media, &m); err != nil || m.Schema == nil {
+		return nil
+	}
+	schema, err := v.schema(m.Schema)
+	if err != nil {
+		return err
+	}
+
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(body))
+	if err != nil {
+		return fmt.Errorf("%s %s %d: тело ответа не является JSON: %w", method, path, status, err)
+	}
+	if !result.Valid() {
+		var errs []error
+		for _, e := range result.Errors() {
+			errs = append(errs, errors.New(e.String()))
+		}
+		return fmt.Errorf("%s %s %d: ответ не соответствует спецификации: %w", method, path, status, errors.Join(errs...))
+	}
+	return nil
+}
+
+// schema компилирует схему ответа вместе с components, чтобы разрешались
+// ссылки вида #/components/schemas/Order.
+func (v *Validator) schema(raw json.RawMessage) (*gojsonschema.Schema, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key := string(raw)
+	if s, ok := v.schemas[key]; ok {
+		return s, nil
+	}
+
+	root, err := json.Marshal(map[string]json.RawMessage{
+		"allOf":      json.RawMessage("[" + key + "]"),
+		"components": v.doc.raw["components"],
+	})
+	if err != nil {
+		return nil, err
+	}
+	s, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(root))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось скомпилировать схему ответа: %w", err)
+	}
+	v.schemas[key] = s
+	return s, nil
+}
+
+// specPath переводит маршрут gin (/order/:id, /ui/*filepath) в путь OpenAPI (/order/{id}).
+func specPath(route string) string {
+	segments := strings.Split(route, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,111 @@
+package pubsub
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Broker рассылает сообщения подписчикам внутри процесса. Publish никогда не
+// блокируется: если буфер подписчика полон, сообщение для него пропускается,
+// а после maxDropped пропусков подряд подписка закрывается как отставшая.
+type Broker[T any] struct {
+	mu         sync.Mutex
+	subs       map[*Subscription[T]]struct{}
+	maxDropped uint64
+	closed     bool
+}
+
+type Subscription[T any] struct {
+	broker  *Broker[T]
+	ch      chan T
+	filter  func(T) bool
+	dropped atomic.Uint64
+	lagged  atomic.Bool
+	once    sync.Once
+}
+
+func NewBroker[T any](maxDropped uint64) *Broker[T] {
+	return &Broker[T]{
+		subs:       make(map[*Subscription[T]]struct{}),
+		maxDropped: maxDropped,
+	}
+}
+
+// Subscribe регистрирует подписчика. filter == nil означает все сообщения.
+// Если брокер уже закрыт, канал подписки сразу закрыт.
+func (b *Broker[T]) Subscribe(buffer int, filter func(T) bool) *Subscription[T] {
+	sub := &Subscription[T]{broker: b, ch: make(chan T, buffer), filter: filter}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		sub.once.Do(func() { close(sub.ch) })
+		return sub
+	}
+	b.subs[sub] = struct{}{}
+	return sub
+}
+
+func (b *Broker[T]) Publish(msg T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		if sub.filter != nil && !sub.filter(msg) {
+			continue
+		}
+		select {
+		case sub.ch <- msg:
+			sub.dropped.Store(0)
+		default:
+			if sub.dropped.Add(1) >= b.maxDropped {
+				sub.lagged.Store(true)
+				b.remove(sub)
+			}
+		}
+	}
+}
+
+func (b *Broker[T]) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// Close закрывает все подписки, чтобы стримы завершились при остановке сервиса.
+func (b *Broker[T]) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subs {
+		b.remove(sub)
+	}
+}
+
+func (b *Broker[T]) remove(sub *Subscription[T]) {
+	delete(b.subs, sub)
+	sub.once.Do(func() { close(sub.ch) })
+}
+
+// C возвращает канал сообщений. Он закрывается при отписке, закрытии брокера
+// или если подписчик отстал.
+func (s *Subscription[T]) C() <-chan T {
+	return s.ch
+}
+
+// Lagged сообщает, что подписка закрыта из-за того, что подписчик не успевал читать.
+func (s *Subscription[T]) Lagged() bool {
+	return s.lagged.Load()
+}
+
+// Dropped возвращает число сообщений, пропущенных подряд с последней успешной доставки.
+func (s *Subscription[T]) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+func (s *Subscription[T]) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s)
+}
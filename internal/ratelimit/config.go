@@ -0,0 +1,40 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+
+	"order-app/config"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+func FromConfig(cfg *config.Config, pool *pgxpool.Pool, logger *zap.Logger) (*Limiter, error) {
+	if !cfg.RateLimitEnabled {
+		return nil, nil
+	}
+
+	def, err := config.ParseRateLimit(cfg.RateLimitDefault)
+	if err != nil {
+		return nil, fmt.Errorf("RATE_LIMIT_DEFAULT: %w", err)
+	}
+	routeSpecs, err := config.ParseRouteRateLimits(cfg.RateLimitRoutes)
+	if err != nil {
+		return nil, fmt.Errorf("RATE_LIMIT_ROUTES: %w", err)
+	}
+	routes := make(map[string]Limit, len(routeSpecs))
+	for route, l := range routeSpecs {
+		routes[route] = Limit(l)
+	}
+
+	const idleTTL = 10 * time.Minute
+	var store Store
+	switch cfg.RateLimitBackend {
+	case "postgres":
+		store = NewPostgresStore(pool, idleTTL, logger)
+	default:
+		store = NewMemoryStore(idleTTL)
+	}
+	return NewLimiter(store, Limit(def), routes, logger), nil
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"strings"
+	"testing"
+
+	"order-app/config"
+
+	"go.uber.org/zap"
+)
+
+func TestFromConfig(t *testing.T) {
+	l, err := FromConfig(&config.Config{RateLimitEnabled: false, RateLimitDefault: "bad"}, nil, zap.NewNop())
+	if err != nil || l != nil {
+		t.Fatalf("выключенный лимит: %v, %v", l, err)
+	}
+
+	cfg := &config.Config{
+		RateLimitEnabled: true,
+		RateLimitBackend: "memory",
+		RateLimitDefault: "10:20",
+		RateLimitRoutes:  "GET /api/order/:order_uid=5:10; POST /api/orders=0.5:1",
+	}
+	l, err = FromConfig(cfg, nil, zap.NewNop())
+	if err != nil {
+		t.Fatalf("FromConfig: %v", err)
+	}
+	if l.def != (Limit{Rate: 10, Burst: 20}) {
+		t.Errorf("лимит по умолчанию %+v", l.def)
+	}
+	want := map[string]Limit{
+		"GET /api/order/:order_uid": {Rate: 5, Burst: 10},
+		"POST /api/orders":          {Rate: 0.5, Burst: 1},
+	}
+	if len(l.routes) != len(want) {
+		t.Fatalf("лимиты маршрутов %v", l.routes)
+	}
+	for route, limit := range want {
+		if l.routes[route] != limit {
+			t.Errorf("%s: %+v, ожидалось %+v", route, l.routes[route], limit)
+		}
+	}
+	if _, ok := l.store.(*MemoryStore); !ok {
+		t.Errorf("хранилище %T, ожидалось *MemoryStore", l.store)
+	}
+
+	for _, tt := range []struct{ name, def, routes, wantErr string }{
+		{"bad default", "ten", "", "RATE_LIMIT_DEFAULT"},
+		{"bad route", "10:20", "GET /x=1", "RATE_LIMIT_ROUTES"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromConfig(&config.Config{RateLimitEnabled: true, RateLimitDefault: tt.def, RateLimitRoutes: tt.routes}, nil, zap.NewNop())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ошибка %v, ожидалось упоминание %s", err, tt.wantErr)
+			}
+		})
+	}
+}
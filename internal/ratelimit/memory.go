@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	idleTTL time.Duration
+	now     func() time.Time
+}
+
+func NewMemoryStore(idleTTL time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		buckets: make(map[string]*bucket),
+		idleTTL: idleTTL,
+		now:     time.Now,
+	}
+	go s.startCleaner()
+	return s
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return Result{Allowed: allowed, Tokens: b.tokens}, nil
+}
+
+func (s *MemoryStore) startCleaner() {
+	ticker := time.NewTicker(s.idleTTL)
+	for range ticker.C {
+		s.cleanUp()
+	}
+}
+
+func (s *MemoryStore) cleanUp() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for key, b := range s.buckets {
+		if now.Sub(b.updated) > s.idleTTL {
+			delete(s.buckets, key)
+		}
+	}
+}
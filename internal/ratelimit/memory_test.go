@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreBurstAndRefill(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	s := &MemoryStore{buckets: make(map[string]*bucket), idleTTL: time.Minute, now: func() time.Time { return now }}
+	limit := Limit{Rate: 2, Burst: 3}
+	ctx := context.Background()
+
+	take := func() Result {
+		t.Helper()
+		res, err := s.Take(ctx, "k", limit)
+		if err != nil {
+			t.Fatalf("Take: %v", err)
+		}
+		return res
+	}
+
+	for i, want := range []float64{2, 1, 0} {
+		if res := take(); !res.Allowed || res.Tokens != want {
+			t.Fatalf("запрос %d из пачки: %+v, ожидалось разрешение и %v токенов", i+1, res, want)
+		}
+	}
+	if res := take(); res.Allowed {
+		t.Fatalf("запрос сверх пачки разрешён: %+v", res)
+	}
+
+	// Через 250 мс при 2 rps набирается полтокена — ещё мало.
+	now = now.Add(250 * time.Millisecond)
+	if res := take(); res.Allowed || math.Abs(res.Tokens-0.5) > 1e-9 {
+		t.Fatalf("после 250 мс: %+v, ожидалось отказ и 0.5 токена", res)
+	}
+
+	now = now.Add(250 * time.Millisecond)
+	if res := take(); !res.Allowed || math.Abs(res.Tokens) > 1e-9 {
+		t.Fatalf("после 500 мс: %+v, ожидалось разрешение и 0 токенов", res)
+	}
+
+	// Долгий простой не даёт накопить больше Burst.
+	now = now.Add(time.Hour)
+	if res := take(); !res.Allowed || res.Tokens != 2 {
+		t.Fatalf("после простоя: %+v, ожидалось Burst-1 токенов", res)
+	}
+
+	if res, _ := s.Take(ctx, "other", limit); !res.Allowed || res.Tokens != 2 {
+		t.Fatalf("у другого ключа свой бакет: %+v", res)
+	}
+}
+
+func TestMemoryStoreCleanUp(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	s := &MemoryStore{buckets: make(map[string]*bucket), idleTTL: time.Minute, now: func() time.Time { return now }}
+	limit := Limit{Rate: 1, Burst: 1}
+	s.Take(context.Background(), "idle", limit)
+	now = now.Add(30 * time.Second)
+	s.Take(context.Background(), "active", limit)
+
+	now = now.Add(45 * time.Second)
+	s.cleanUp()
+	if _, ok := s.buckets["idle"]; ok {
+		t.Error("простаивающий бакет не удалён")
+	}
+	if _, ok := s.buckets["active"]; !ok {
+		t.Error("активный бакет удалён")
+	}
+}
+
+func TestLimitTimings(t *testing.T) {
+	limit := Limit{Rate: 4, Burst: 10}
+	tests := []struct {
+		tokens     float64
+		retryAfter time.Duration
+		resetAfter time.Duration
+	}{
+		{tokens: 10, retryAfter: 0, resetAfter: 0},
+		{tokens: 1, retryAfter: 0, resetAfter: 2250 * time.Millisecond},
+		{tokens: 0.5, retryAfter: 125 * time.Millisecond, resetAfter: 2375 * time.Millisecond},
+		{tokens: 0, retryAfter: 250 * time.Millisecond, resetAfter: 2500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := limit.RetryAfter(tt.tokens); got != tt.retryAfter {
+			t.Errorf("RetryAfter(%v) = %s, ожидалось %s", tt.tokens, got, tt.retryAfter)
+		}
+		if got := limit.ResetAfter(tt.tokens); got != tt.resetAfter {
+			t.Errorf("ResetAfter(%v) = %s, ожидалось %s", tt.tokens, got, tt.resetAfter)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"order-app/internal/auth"
+	"order-app/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// verifiedTTL — сколько учётные данные после успешной проверки считаются своим
+// ключом лимита, не дожидаясь новой проверки.
+const verifiedTTL = 10 * time.Minute
+
+type Limiter struct {
+	store  Store
+	def    Limit
+	routes map[string]Limit
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	verified map[string]time.Time
+	prunedAt time.Time
+	now      func() time.Time
+}
+
+func NewLimiter(store Store, def Limit, routes map[string]Limit, logger *zap.Logger) *Limiter {
+	return &Limiter{
+		store:    store,
+		def:      def,
+		routes:   routes,
+		logger:   logger,
+		verified: make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// Limit ограничивает частоту запросов к маршруту. Ставится перед auth.Identify,
+// чтобы поток запросов с поддельными токенами упирался в лимит до проверки подписи.
+// Учётные данные, которые уже проходили проверку, получают собственный бакет по
+// хэшу; запросы без них, с неверными или ещё не проверенными учитываются по IP, так
+// что перебор ключей и токенов тоже ограничен.
+func (l *Limiter) Limit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l == nil {
+			c.Next()
+			return
+		}
+
+		route := c.Request.Method + " " + c.FullPath()
+		limit, ok := l.routes[route]
+		if !ok {
+			limit = l.def
+		}
+
+		digest := credentialDigest(c.Request)
+		res, err := l.store.Take(c.Request.Context(), route+"|"+l.clientKey(c, digest), limit)
+		if err != nil {
+			logger.FromContext(c.Request.Context(), l.logger).Error("Не удалось проверить лимит запросов, запрос пропущен", zap.Error(err))
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(int(math.Max(0, res.Tokens))))
+		c.Header("X-RateLimit-Reset", seconds(limit.ResetAfter(res.Tokens).Seconds()))
+
+		if !res.Allowed {
+			c.Header("Retry-After", seconds(limit.RetryAfter(res.Tokens).Seconds()))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			return
+		}
+		c.Next()
+
+		if digest != "" && auth.FromContext(c) != nil {
+			l.markVerified(digest)
+		}
+	}
+}
+
+func (l *Limiter) clientKey(c *gin.Context, digest string) string {
+	if digest != "" && l.isVerified(digest) {
+		return "cred:" + digest
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func (l *Limiter) isVerified(digest string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	until, ok := l.verified[digest]
+	return ok && l.now().Before(until)
+}
+
+func (l *Limiter) markVerified(digest string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if now.Sub(l.prunedAt) > verifiedTTL {
+		for d, until := range l.verified {
+			if !now.Before(until) {
+				delete(l.verified, d)
+			}
+		}
+		l.prunedAt = now
+	}
+	l.verified[digest] = now.Add(verifiedTTL)
+}
+
+// credentialDigest возвращает хэш учётных данных запроса, чтобы не держать в
+// памяти и в ключах хранилища сами ключи и токены.
+func credentialDigest(r *http.Request) string {
+	cred := auth.Credential(r)
+	if cred == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(cred))
+	return hex.EncodeToString(sum[:16])
+}
+
+func seconds(s float64) string {
+	return strconv.Itoa(int(math.Ceil(s)))
+}
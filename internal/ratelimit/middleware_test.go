@@ -0,0 +1,234 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"order-app/internal/auth"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const validKey = "valid-key-0123456789"
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestRouter собирает цепочку как в cmd/serve.go: лимит, затем аутентификация.
+func newTestRouter(t *testing.T, l *Limiter) *gin.Engine {
+	t.Helper()
+
+	digest := sha256.Sum256([]byte(validKey))
+	keys, _ := json.Marshal([]map[string]any{
+		{"name": "bot", "hash": "sha256:" + hex.EncodeToString(digest[:]), "scopes": []string{auth.ScopeOrdersRead}},
+	})
+	keysFile := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(keysFile, keys, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	a, err := auth.NewAuthenticator(auth.Options{APIKeysFile: keysFile})
+	if err != nil {
+		t.Fatalf("NewAuthenticator: %v", err)
+	}
+
+	r := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/public", l.Limit(), ok)
+	r.GET("/slow", l.Limit(), ok)
+	r.GET("/orders", l.Limit(), a.Identify(), a.Require(auth.ScopeOrdersRead), ok)
+	return r
+}
+
+func newTestLimiter(def Limit, routes map[string]Limit) (*Limiter, *time.Time) {
+	now := time.Unix(1_700_000_000, 0)
+	clock := func() time.Time { return now }
+	store := &MemoryStore{buckets: make(map[string]*bucket), idleTTL: time.Hour, now: clock}
+	l := NewLimiter(store, def, routes, zap.NewNop())
+	l.now = clock
+	return l, &now
+}
+
+func do(r http.Handler, path, ip, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = ip + ":12345"
+	if key != "" {
+		req.Header.Set(auth.APIKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestLimitHeaders(t *testing.T) {
+	l, now := newTestLimiter(Limit{Rate: 0.5, Burst: 2}, nil)
+	r := newTestRouter(t, l)
+
+	for i, want := range []struct{ remaining, reset string }{{"1", "2"}, {"0", "4"}} {
+		w := do(r, "/public", "192.0.2.1", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("запрос %d: статус %d", i+1, w.Code)
+		}
+		h := w.Header()
+		if h.Get("X-RateLimit-Limit") != "2" || h.Get("X-RateLimit-Remaining") != want.remaining || h.Get("X-RateLimit-Reset") != want.reset {
+			t.Errorf("запрос %d: заголовки %v, ожидалось Remaining=%s Reset=%s", i+1, h, want.remaining, want.reset)
+		}
+		if h.Get("Retry-After") != "" {
+			t.Errorf("запрос %d: Retry-After у разрешённого запроса", i+1)
+		}
+	}
+
+	*now = now.Add(500 * time.Millisecond)
+	w := do(r, "/public", "192.0.2.1", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("статус %d, ожидался 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, ожидалось 2 (1.5 с с округлением вверх)", got)
+	}
+	if got := w.Header().Get("X-RateLimit-Reset"); got != "4" {
+		t.Errorf("X-RateLimit-Reset = %q, ожидалось 4", got)
+	}
+	if got := w.Body.String(); got != `{"error":"Too many requests"}` {
+		t.Errorf("тело ответа %s", got)
+	}
+
+	*now = now.Add(1500 * time.Millisecond)
+	if w := do(r, "/public", "192.0.2.1", ""); w.Code != http.StatusOK {
+		t.Errorf("после пополнения статус %d", w.Code)
+	}
+}
+
+func TestRouteLimits(t *testing.T) {
+	l, _ := newTestLimiter(Limit{Rate: 1, Burst: 5}, map[string]Limit{"GET /slow": {Rate: 1, Burst: 1}})
+	r := newTestRouter(t, l)
+
+	if w := do(r, "/slow", "192.0.2.1", ""); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "1" {
+		t.Fatalf("/slow: статус %d, лимит %q", w.Code, w.Header().Get("X-RateLimit-Limit"))
+	}
+	if w := do(r, "/slow", "192.0.2.1", ""); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("/slow: второй запрос получил %d", w.Code)
+	}
+	// У маршрутов свои бакеты: исчерпанный /slow не трогает /public.
+	if w := do(r, "/public", "192.0.2.1", ""); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "5" {
+		t.Fatalf("/public: статус %d, лимит %q", w.Code, w.Header().Get("X-RateLimit-Limit"))
+	}
+}
+
+func TestKeySelection(t *testing.T) {
+	t.Run("anonymous by ip", func(t *testing.T) {
+		l, _ := newTestLimiter(Limit{Rate: 1, Burst: 1}, nil)
+		r := newTestRouter(t, l)
+
+		do(r, "/public", "192.0.2.1", "")
+		if w := do(r, "/public", "192.0.2.1", ""); w.Code != http.StatusTooManyRequests {
+			t.Errorf("тот же IP: статус %d", w.Code)
+		}
+		if w := do(r, "/public", "192.0.2.2", ""); w.Code != http.StatusOK {
+			t.Errorf("другой IP: статус %d", w.Code)
+		}
+	})
+
+	t.Run("rotating invalid credentials share ip bucket", func(t *testing.T) {
+		l, _ := newTestLimiter(Limit{Rate: 1, Burst: 3}, nil)
+		r := newTestRouter(t, l)
+
+		for i := range 3 {
+			if w := do(r, "/orders", "192.0.2.1", "guess-"+string(rune('a'+i))); w.Code != http.StatusUnauthorized {
+				t.Fatalf("попытка %d: статус %d", i+1, w.Code)
+			}
+		}
+		if w := do(r, "/orders", "192.0.2.1", "guess-z"); w.Code != http.StatusTooManyRequests {
+			t.Errorf("перебор ключей не ограничен: статус %d", w.Code)
+		}
+		if len(l.verified) != 0 {
+			t.Errorf("неверные ключи помечены проверенными: %v", l.verified)
+		}
+	})
+
+	t.Run("verified credential gets own bucket", func(t *testing.T) {
+		l, now := newTestLimiter(Limit{Rate: 1, Burst: 2}, nil)
+		r := newTestRouter(t, l)
+
+		// Первый запрос с ключом ещё идёт по IP, после проверки ключ получает свой бакет.
+		if w := do(r, "/orders", "192.0.2.1", validKey); w.Code != http.StatusOK {
+			t.Fatalf("первый запрос с ключом: статус %d", w.Code)
+		}
+		if w := do(r, "/orders", "192.0.2.1", ""); w.Code != http.StatusUnauthorized {
+			t.Fatalf("анонимный запрос: статус %d", w.Code)
+		}
+		if w := do(r, "/orders", "192.0.2.1", ""); w.Code != http.StatusTooManyRequests {
+			t.Fatalf("бакет IP не исчерпан: статус %d", w.Code)
+		}
+		for i := range 2 {
+			if w := do(r, "/orders", "192.0.2.1", validKey); w.Code != http.StatusOK {
+				t.Fatalf("запрос %d с проверенным ключом: статус %d", i+1, w.Code)
+			}
+		}
+		if w := do(r, "/orders", "192.0.2.2", validKey); w.Code != http.StatusTooManyRequests {
+			t.Errorf("бакет ключа общий для всех IP: статус %d", w.Code)
+		}
+
+		// По истечении verifiedTTL ключ снова учитывается по IP до новой проверки.
+		*now = now.Add(verifiedTTL)
+		if l.isVerified(credentialDigest(withKey(validKey))) {
+			t.Error("ключ считается проверенным после verifiedTTL")
+		}
+	})
+}
+
+func withKey(key string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(auth.APIKeyHeader, key)
+	return req
+}
+
+func TestCredentialDigest(t *testing.T) {
+	bearer := httptest.NewRequest(http.MethodGet, "/", nil)
+	bearer.Header.Set("Authorization", "Bearer token-1")
+
+	if d := credentialDigest(httptest.NewRequest(http.MethodGet, "/", nil)); d != "" {
+		t.Errorf("без учётных данных: %q", d)
+	}
+	if d := credentialDigest(withKey(validKey)); len(d) != 32 || d == credentialDigest(bearer) {
+		t.Errorf("дайджест ключа %q, токена %q", d, credentialDigest(bearer))
+	}
+	if credentialDigest(withKey(validKey)) != credentialDigest(withKey(validKey)) {
+		t.Error("дайджест одного ключа различается")
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, Limit) (Result, error) {
+	return Result{}, errors.New("хранилище недоступно")
+}
+
+func TestLimitPassesThrough(t *testing.T) {
+	t.Run("nil limiter", func(t *testing.T) {
+		var l *Limiter
+		r := newTestRouter(t, l)
+		for range 3 {
+			if w := do(r, "/public", "192.0.2.1", ""); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "" {
+				t.Fatalf("статус %d, заголовки %v", w.Code, w.Header())
+			}
+		}
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		r := newTestRouter(t, NewLimiter(failingStore{}, Limit{Rate: 1, Burst: 1}, nil, zap.NewNop()))
+		if w := do(r, "/public", "192.0.2.1", ""); w.Code != http.StatusOK {
+			t.Fatalf("статус %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+const takeQuery = `
+	INSERT INTO rate_limit_buckets (key, tokens, allowed, updated_at)
+	VALUES ($1, $2::float8 - 1, true, now())
+	ON CONFLICT (key) DO UPDATE SET
+		tokens = CASE
+			WHEN LEAST($2::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM now() - rate_limit_buckets.updated_at) * $3::float8) >= 1
+			THEN LEAST($2::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM now() - rate_limit_buckets.updated_at) * $3::float8) - 1
+			ELSE LEAST($2::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM now() - rate_limit_buckets.updated_at) * $3::float8)
+		END,
+		allowed = LEAST($2::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM now() - rate_limit_buckets.updated_at) * $3::float8) >= 1,
+		updated_at = now()
+	RETURNING tokens, allowed;
+`
+
+// cleanUpQuery удаляет корзины, к которым давно не обращались: за idleTTL они
+// успевают наполниться, так что удаление не меняет лимит клиента.
+const cleanUpQuery = `DELETE FROM rate_limit_buckets WHERE updated_at < now() - make_interval(secs => $1);`
+
+type PostgresStore struct {
+	pool    *pgxpool.Pool
+	idleTTL time.Duration
+	logger  *zap.Logger
+}
+
+func NewPostgresStore(pool *pgxpool.Pool, idleTTL time.Duration, logger *zap.Logger) *PostgresStore {
+	s := &PostgresStore{pool: pool, idleTTL: idleTTL, logger: logger}
+	go s.startCleaner()
+	return s
+}
+
+func (s *PostgresStore) startCleaner() {
+	ticker := time.NewTicker(s.idleTTL)
+	for range ticker.C {
+		s.cleanUp(context.Background())
+	}
+}
+
+func (s *PostgresStore) cleanUp(ctx context.Context) {
+	tag, err := s.pool.Exec(ctx, cleanUpQuery, s.idleTTL.Seconds())
+	if err != nil {
+		s.logger.Warn("Не удалось удалить устаревшие счётчики запросов", zap.Error(err))
+		return
+	}
+	s.logger.Debug("Удалены устаревшие счётчики запросов", zap.Int64("count", tag.RowsAffected()))
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	var res Result
+	if err := s.pool.QueryRow(ctx, takeQuery, key, limit.Burst, limit.Rate).Scan(&res.Tokens, &res.Allowed); err != nil {
+		return Result{}, fmt.Errorf("не удалось обновить счётчик запросов: %w", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"order-app/config"
+	"order-app/db"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// TEST_DATABASE_URL указывает на пустую базу, в которой тест создаёт свою схему.
+// Без неё проверка на Postgres пропускается.
+const testDSNEnv = "TEST_DATABASE_URL"
+
+func TestPostgresStore(t *testing.T) {
+	pool := testPool(t, "ratelimit_store")
+	s := &PostgresStore{pool: pool, idleTTL: time.Hour, logger: zap.NewNop()}
+	ctx := context.Background()
+	limit := Limit{Rate: 0.001, Burst: 2}
+
+	for i, want := range []bool{true, true, false} {
+		res, err := s.Take(ctx, "k", limit)
+		if err != nil {
+			t.Fatalf("Take: %v", err)
+		}
+		if res.Allowed != want {
+			t.Fatalf("запрос %d: %+v, ожидалось Allowed=%v", i+1, res, want)
+		}
+	}
+	if res, _ := s.Take(ctx, "other", limit); !res.Allowed || res.Tokens != 1 {
+		t.Fatalf("у другого ключа свой бакет: %+v", res)
+	}
+
+	// Сдвигаем время обновления назад: бакет пополняется, но не выше Burst.
+	if _, err := pool.Exec(ctx, `UPDATE rate_limit_buckets SET updated_at = now() - interval '1 hour' WHERE key = 'k'`); err != nil {
+		t.Fatal(err)
+	}
+	res, err := s.Take(ctx, "k", limit)
+	if err != nil || !res.Allowed || res.Tokens != 1 {
+		t.Fatalf("после пополнения: %+v, %v", res, err)
+	}
+
+	s.idleTTL = 30 * time.Minute
+	if _, err := pool.Exec(ctx, `UPDATE rate_limit_buckets SET updated_at = now() - interval '1 hour' WHERE key = 'other'`); err != nil {
+		t.Fatal(err)
+	}
+	s.cleanUp(ctx)
+	var keys []string
+	rows, _ := pool.Query(ctx, `SELECT key FROM rate_limit_buckets ORDER BY key`)
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, k)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0] != "k" {
+		t.Errorf("после очистки остались %v, ожидался только k", keys)
+	}
+}
+
+func testPool(t *testing.T, schema string) *pgxpool.Pool {
+	t.Helper()
+
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s не задан, тесты на Postgres пропущены", testDSNEnv)
+	}
+	ctx := context.Background()
+
+	admin, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("подключение к %s: %v", testDSNEnv, err)
+	}
+	_, err = admin.Exec(ctx, fmt.Sprintf("DROP SCHEMA IF EXISTS %[1]s CASCADE; CREATE SCHEMA %[1]s", schema))
+	admin.Close()
+	if err != nil {
+		t.Fatalf("создание схемы %s: %v", schema, err)
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("разбор %s: %v", testDSNEnv, err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+
+	m, err := db.NewMigrator(&config.Config{DatabaseURL: u.String()})
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	defer m.Close()
+	if err := m.Up(); err != nil {
+		t.Fatalf("миграции: %v", err)
+	}
+
+	pool, err := pgxpool.New(ctx, u.String())
+	if err != nil {
+		t.Fatalf("подключение к схеме %s: %v", schema, err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
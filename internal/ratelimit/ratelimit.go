@@ -0,0 +1,34 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result описывает решение по запросу; Tokens — остаток бакета после списания.
+type Result struct {
+	Allowed bool
+	Tokens  float64
+}
+
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// RetryAfter возвращает время до появления в бакете целого токена.
+func (l Limit) RetryAfter(tokens float64) time.Duration {
+	if tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - tokens) / l.Rate * float64(time.Second))
+}
+
+// ResetAfter возвращает время до полного восполнения бакета.
+func (l Limit) ResetAfter(tokens float64) time.Duration {
+	return time.Duration((float64(l.Burst) - tokens) / l.Rate * float64(time.Second))
+}
@@ -0,0 +1,184 @@
+package redact
+
+import (
+	"encoding/json"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var sensitiveKeys = map[string]func(string) string{
+	"name":    Name,
+	"phone":   Phone,
+	"email":   Email,
+	"address": Full,
+	"zip":     Full,
+}
+
+type encoder struct {
+	zapcore.Encoder
+}
+
+func NewEncoder(enc zapcore.Encoder) zapcore.Encoder {
+	return &encoder{Encoder: enc}
+}
+
+func (e *encoder) Clone() zapcore.Encoder {
+	return &encoder{Encoder: e.Encoder.Clone()}
+}
+
+// Поля, добавленные через logger.With, не проходят через EncodeEntry: zap сразу
+// пишет их в энкодер методами ObjectEncoder. Поэтому маскируются и эти методы —
+// так же, как поля самой записи в maskField.
+
+func (e *encoder) AddString(key, val string) {
+	e.Encoder.AddString(key, maskValue(key, val))
+}
+
+func (e *encoder) AddByteString(key string, val []byte) {
+	e.Encoder.AddString(key, maskValue(key, string(val)))
+}
+
+// AddBinary пишет непрозрачные байты; под чувствительным ключом они заменяются
+// маской целиком.
+func (e *encoder) AddBinary(key string, val []byte) {
+	if mask, ok := sensitiveKeys[strings.ToLower(key)]; ok {
+		e.Encoder.AddString(key, mask(string(val)))
+		return
+	}
+	e.Encoder.AddBinary(key, val)
+}
+
+func (e *encoder) AddReflected(key string, val any) error {
+	return e.addMasked(zap.Reflect(key, val))
+}
+
+func (e *encoder) AddObject(key string, val zapcore.ObjectMarshaler) error {
+	return e.addMasked(zap.Object(key, val))
+}
+
+func (e *encoder) AddArray(key string, val zapcore.ArrayMarshaler) error {
+	return e.addMasked(zap.Array(key, val))
+}
+
+func (e *encoder) addMasked(f zapcore.Field) error {
+	maskField(f).AddTo(e.Encoder)
+	return nil
+}
+
+func (e *encoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	ent.Message = String(ent.Message)
+
+	masked := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		masked[i] = maskField(f)
+	}
+	return e.Encoder.EncodeEntry(ent, masked)
+}
+
+func maskField(f zapcore.Field) zapcore.Field {
+	switch f.Type {
+	case zapcore.StringType:
+		f.String = maskValue(f.Key, f.String)
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok && err != nil {
+			return zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: String(err.Error())}
+		}
+	case zapcore.StringerType:
+		if s, ok := f.Interface.(interface{ String() string }); ok && s != nil {
+			return zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: maskValue(f.Key, s.String())}
+		}
+	case zapcore.ByteStringType:
+		if b, ok := f.Interface.([]byte); ok {
+			return zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: maskValue(f.Key, string(b))}
+		}
+	case zapcore.BinaryType:
+		if mask, ok := sensitiveKeys[strings.ToLower(f.Key)]; ok {
+			if b, ok := f.Interface.([]byte); ok {
+				return zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: mask(string(b))}
+			}
+		}
+	case zapcore.ReflectType:
+		// zap.Any и zap.Reflect со структурами (например, целым domain.Order):
+		// значение приводится к JSON-дереву и маскируется по именам полей.
+		if f.Interface == nil {
+			return f
+		}
+		raw, err := json.Marshal(f.Interface)
+		if err != nil {
+			return zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: "[не удалось замаскировать значение]"}
+		}
+		var tree any
+		if err := json.Unmarshal(raw, &tree); err != nil {
+			return zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: "[не удалось замаскировать значение]"}
+		}
+		return zapcore.Field{Key: f.Key, Type: zapcore.ReflectType, Interface: maskTree(f.Key, tree)}
+	case zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType, zapcore.InlineMarshalerType:
+		// Маршалеры пишут в энкодер напрямую, минуя AddString, поэтому сначала
+		// собираем их вывод в дерево, а затем маскируем его.
+		enc := zapcore.NewMapObjectEncoder()
+		if err := addMarshaler(enc, f); err != nil {
+			return zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: "[не удалось замаскировать значение]"}
+		}
+		if f.Type == zapcore.InlineMarshalerType {
+			return zapcore.Field{Type: zapcore.InlineMarshalerType, Interface: maskedFields(maskTree("", enc.Fields).(map[string]any))}
+		}
+		return zapcore.Field{Key: f.Key, Type: zapcore.ReflectType, Interface: maskTree(f.Key, enc.Fields[f.Key])}
+	}
+	return f
+}
+
+func addMarshaler(enc *zapcore.MapObjectEncoder, f zapcore.Field) error {
+	switch f.Type {
+	case zapcore.ObjectMarshalerType:
+		return enc.AddObject(f.Key, f.Interface.(zapcore.ObjectMarshaler))
+	case zapcore.ArrayMarshalerType:
+		return enc.AddArray(f.Key, f.Interface.(zapcore.ArrayMarshaler))
+	default:
+		return f.Interface.(zapcore.ObjectMarshaler).MarshalLogObject(enc)
+	}
+}
+
+// maskTree маскирует строки во вложенных map/slice: значения чувствительных ключей —
+// соответствующей функцией, остальные — как свободный текст.
+func maskTree(key string, v any) any {
+	switch v := v.(type) {
+	case string:
+		return maskValue(key, v)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			out[k] = maskTree(k, val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, val := range v {
+			out[i] = maskTree(key, val)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// maskedFields встраивает уже замаскированные поля в объект лога.
+type maskedFields map[string]any
+
+func (m maskedFields) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for k, v := range m {
+		if err := enc.AddReflected(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func maskValue(key, val string) string {
+	if mask, ok := sensitiveKeys[strings.ToLower(key)]; ok {
+		return mask(val)
+	}
+	return String(val)
+}
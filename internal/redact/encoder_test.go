@@ -0,0 +1,104 @@
+package redact_test
+
+import (
+	"strings"
+	"testing"
+
+	"order-app/internal/domain"
+	"order-app/internal/redact"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type deliveryObject domain.DeliveryInfo
+
+func (d deliveryObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", d.Name)
+	enc.AddString("phone", d.Phone)
+	return enc.AddObject("contact", contactObject{email: d.Email})
+}
+
+type contactObject struct{ email string }
+
+func (c contactObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("email", c.email)
+	return nil
+}
+
+var (
+	testDelivery = domain.DeliveryInfo{
+		Name:    "Ivan Petrov",
+		Phone:   "+79991234567",
+		Email:   "ivan.petrov@example.com",
+		Address: "Lenina 1",
+		Zip:     "123456",
+	}
+	secrets = []string{"Petrov", "1234567", "ivan.petrov", "Lenina", "123456"}
+)
+
+// structuredFields — поля всех видов, которыми в лог может попасть заказ.
+func structuredFields() []struct {
+	name  string
+	field zap.Field
+} {
+	delivery := testDelivery
+	order := &domain.Order{OrderUID: "uid-1", Delivery: delivery}
+	return []struct {
+		name  string
+		field zap.Field
+	}{
+		{"any", zap.Any("order", order)},
+		{"reflect", zap.Reflect("delivery", delivery)},
+		{"object", zap.Object("delivery", deliveryObject(delivery))},
+		{"inline", zap.Inline(deliveryObject(delivery))},
+		{"objects", zap.Objects("deliveries", []deliveryObject{deliveryObject(delivery)})},
+		{"bytestring", zap.ByteString("email", []byte(delivery.Email))},
+		{"binary", zap.Binary("phone", []byte(delivery.Phone))},
+		{"string", zap.String("name", delivery.Name)},
+	}
+}
+
+func TestEncoderMasksStructuredFields(t *testing.T) {
+	tests := structuredFields()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc := redact.NewEncoder(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()))
+			buf, err := enc.EncodeEntry(zapcore.Entry{Message: "test"}, []zapcore.Field{tt.field})
+			if err != nil {
+				t.Fatalf("EncodeEntry: %v", err)
+			}
+			out := buf.String()
+			for _, secret := range secrets {
+				if strings.Contains(out, secret) {
+					t.Errorf("в логе осталось %q: %s", secret, out)
+				}
+			}
+		})
+	}
+}
+
+// Поля из logger.With zap пишет в энкодер сразу, а не через EncodeEntry.
+func TestEncoderMasksContextFields(t *testing.T) {
+	for _, tt := range structuredFields() {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			enc := redact.NewEncoder(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()))
+			l := zap.New(zapcore.NewCore(enc, zapcore.AddSync(&out), zap.DebugLevel))
+
+			l.With(tt.field).Info("test")
+			l.With(zap.String("order_uid", "uid-1")).With(tt.field).Info("nested")
+			l.With(zap.Namespace("ctx"), tt.field).Info("namespace")
+
+			if !strings.Contains(out.String(), "namespace") {
+				t.Fatalf("записи не попали в лог: %s", out.String())
+			}
+			for _, secret := range secrets {
+				if strings.Contains(out.String(), secret) {
+					t.Errorf("в логе осталось %q: %s", secret, out.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package redact
+
+import (
+	"regexp"
+	"strings"
+
+	"order-app/internal/domain"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	phonePattern = regexp.MustCompile(`\+\d[\d\-\s()]{7,}\d`)
+)
+
+func Order(o *domain.Order) *domain.Order {
+	if o == nil {
+		return nil
+	}
+	masked := *o
+	masked.Delivery = Delivery(o.Delivery)
+	return &masked
+}
+
+func Delivery(d domain.DeliveryInfo) domain.DeliveryInfo {
+	return domain.DeliveryInfo{
+		Name:    Name(d.Name),
+		Phone:   Phone(d.Phone),
+		Zip:     Partial(d.Zip, 2, 0),
+		City:    d.City,
+		Address: Full(d.Address),
+		Region:  d.Region,
+		Email:   Email(d.Email),
+	}
+}
+
+func Name(name string) string {
+	parts := strings.Fields(name)
+	for i, p := range parts {
+		parts[i] = Partial(p, 1, 0)
+	}
+	return strings.Join(parts, " ")
+}
+
+func Phone(phone string) string {
+	return Partial(phone, 3, 2)
+}
+
+func Email(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return Full(email)
+	}
+	return Partial(email[:at], 1, 0) + email[at:]
+}
+
+func Full(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "***"
+}
+
+func Partial(s string, keepPrefix, keepSuffix int) string {
+	r := []rune(s)
+	if len(r) <= keepPrefix+keepSuffix {
+		return strings.Repeat("*", len(r))
+	}
+	return string(r[:keepPrefix]) + strings.Repeat("*", len(r)-keepPrefix-keepSuffix) + string(r[len(r)-keepSuffix:])
+}
+
+func String(s string) string {
+	s = emailPattern.ReplaceAllStringFunc(s, Email)
+	return phonePattern.ReplaceAllStringFunc(s, Phone)
+}
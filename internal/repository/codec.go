@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"order-app/internal/domain"
+	"order-app/internal/fieldcrypt"
+)
+
+var ErrEncryptionNotConfigured = errors.New("заказ зашифрован, но ключи шифрования не настроены")
+
+type storedOrder struct {
+	*domain.Order
+	PII *fieldcrypt.Envelope `json:"pii,omitempty"`
+}
+
+type deliveryPII struct {
+	Name    string `json:"name"`
+	Phone   string `json:"phone"`
+	Email   string `json:"email"`
+	Address string `json:"address"`
+}
+
+func encodeOrder(keyring *fieldcrypt.Keyring, order *domain.Order) ([]byte, error) {
+	if keyring == nil {
+		return json.Marshal(order)
+	}
+
+	plaintext, err := json.Marshal(deliveryPII{
+		Name:    order.Delivery.Name,
+		Phone:   order.Delivery.Phone,
+		Email:   order.Delivery.Email,
+		Address: order.Delivery.Address,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	env, err := keyring.Seal(plaintext, []byte(order.OrderUID))
+	if err != nil {
+		return nil, err
+	}
+
+	stripped := *order
+	stripped.Delivery.Name = ""
+	stripped.Delivery.Phone = ""
+	stripped.Delivery.Email = ""
+	stripped.Delivery.Address = ""
+	return json.Marshal(storedOrder{Order: &stripped, PII: env})
+}
+
+func decodeOrder(keyring *fieldcrypt.Keyring, data []byte) (*domain.Order, error) {
+	stored, err := unmarshalStored(data)
+	if err != nil {
+		return nil, err
+	}
+	if stored.PII == nil {
+		return stored.Order, nil
+	}
+	if keyring == nil {
+		return nil, ErrEncryptionNotConfigured
+	}
+
+	plaintext, err := keyring.Open(stored.PII, []byte(stored.OrderUID))
+	if err != nil {
+		return nil, fmt.Errorf("заказ %s: %w", stored.OrderUID, err)
+	}
+
+	var pii deliveryPII
+	if err := json.Unmarshal(plaintext, &pii); err != nil {
+		return nil, fmt.Errorf("заказ %s: не удалось разобрать персональные данные: %w", stored.OrderUID, err)
+	}
+
+	order := stored.Order
+	order.Delivery.Name = pii.Name
+	order.Delivery.Phone = pii.Phone
+	order.Delivery.Email = pii.Email
+	order.Delivery.Address = pii.Address
+	return order, nil
+}
+
+// reencode возвращает новое содержимое записи, если она хранится в открытом виде
+// или зашифрована не основным ключом, и nil, если запись менять не нужно.
+func reencode(keyring *fieldcrypt.Keyring, data []byte) ([]byte, error) {
+	stored, err := unmarshalStored(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if stored.PII == nil {
+		return encodeOrder(keyring, stored.Order)
+	}
+
+	changed, err := keyring.Rewrap(stored.PII)
+	if err != nil || !changed {
+		return nil, err
+	}
+	return json.Marshal(stored)
+}
+
+func unmarshalStored(data []byte) (*storedOrder, error) {
+	stored := &storedOrder{Order: &domain.Order{}}
+	if err := json.Unmarshal(data, stored); err != nil {
+		return nil, fmt.Errorf("не удалось распарсить заказ: %w", err)
+	}
+	return stored, nil
+}
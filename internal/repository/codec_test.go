@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"order-app/internal/domain"
+	"order-app/internal/fieldcrypt"
+)
+
+func testKeyring(t *testing.T, primary string) *fieldcrypt.Keyring {
+	t.Helper()
+	ring, err := fieldcrypt.NewKeyring(primary, map[string][]byte{
+		"k1": bytes.Repeat([]byte{0x11}, 32),
+		"k2": bytes.Repeat([]byte{0x22}, 32),
+	})
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return ring
+}
+
+func codecOrder(uid string) *domain.Order {
+	return &domain.Order{
+		OrderUID: uid,
+		Delivery: domain.DeliveryInfo{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+		CustomerID: "test",
+	}
+}
+
+func TestDecodeOrder(t *testing.T) {
+	order := codecOrder("uid-codec")
+	legacy, err := json.Marshal(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := encodeOrder(testKeyring(t, "k1"), order)
+	if err != nil {
+		t.Fatalf("encodeOrder: %v", err)
+	}
+	if bytes.Contains(encrypted, []byte(order.Delivery.Email)) || bytes.Contains(encrypted, []byte(order.Delivery.Name)) {
+		t.Fatalf("персональные данные сохранены в открытом виде: %s", encrypted)
+	}
+
+	tests := []struct {
+		name    string
+		keyring *fieldcrypt.Keyring
+		data    []byte
+		wantErr error
+	}{
+		{name: "legacy plaintext without keys", data: legacy},
+		{name: "legacy plaintext with keys", keyring: testKeyring(t, "k1"), data: legacy},
+		{name: "encrypted", keyring: testKeyring(t, "k1"), data: encrypted},
+		{name: "encrypted after rotation", keyring: testKeyring(t, "k2"), data: encrypted},
+		{name: "encrypted without keys", data: encrypted, wantErr: ErrEncryptionNotConfigured},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeOrder(tt.keyring, tt.data)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("decodeOrder вернул %v, ожидалось %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeOrder: %v", err)
+			}
+			if got.OrderUID != order.OrderUID || got.Delivery != order.Delivery {
+				t.Fatalf("decodeOrder вернул %+v, ожидалось %+v", got.Delivery, order.Delivery)
+			}
+		})
+	}
+}
+
+func TestDecodeOrderRejectsMovedCiphertext(t *testing.T) {
+	ring := testKeyring(t, "k1")
+	data, err := encodeOrder(ring, codecOrder("uid-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Шифротекст, перенесённый в запись другого заказа, не должен расшифровываться.
+	moved := bytes.Replace(data, []byte(`"order_uid":"uid-a"`), []byte(`"order_uid":"uid-b"`), 1)
+	if _, err := decodeOrder(ring, moved); err == nil {
+		t.Fatal("decodeOrder должен отвергнуть шифротекст чужого заказа")
+	}
+}
+
+func TestReencode(t *testing.T) {
+	order := codecOrder("uid-reencode")
+	legacy, _ := json.Marshal(order)
+	oldRing, newRing := testKeyring(t, "k1"), testKeyring(t, "k2")
+
+	encrypted, err := reencode(oldRing, legacy)
+	if err != nil || encrypted == nil {
+		t.Fatalf("reencode открытой записи = %v, %v", encrypted != nil, err)
+	}
+	if same, err := reencode(oldRing, encrypted); err != nil || same != nil {
+		t.Fatalf("запись с основным ключом не должна меняться: %v, %v", same != nil, err)
+	}
+
+	rotated, err := reencode(newRing, encrypted)
+	if err != nil || rotated == nil {
+		t.Fatalf("reencode после ротации = %v, %v", rotated != nil, err)
+	}
+	stored, err := unmarshalStored(rotated)
+	if err != nil || stored.PII == nil || stored.PII.KeyID != "k2" {
+		t.Fatalf("после ротации ожидался kid k2: %+v, %v", stored.PII, err)
+	}
+	got, err := decodeOrder(newRing, rotated)
+	if err != nil || got.Delivery != order.Delivery {
+		t.Fatalf("decodeOrder после ротации = %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// transientClasses — классы SQLSTATE, при которых запрос имеет смысл повторить:
+// 08 — нет соединения, 40 — конфликт сериализации или взаимоблокировка,
+// 53 — серверу не хватает ресурсов, 57 — сервер перезапускается или запрос
+// отменён по таймауту.
+var transientClasses = []string{"08", "40", "53", "57"}
+
+// IsTransient сообщает, что ошибка хранилища временная: связь с базой потеряна,
+// истёк таймаут или база перегружена. Остальные ошибки, в том числе
+// ErrInvalidOrder, повтор не исправит.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, ErrInvalidOrder) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		for _, class := range transientClasses {
+			if strings.HasPrefix(pgErr.Code, class) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var (
+		connErr *pgconn.ConnectError
+		netErr  net.Error
+	)
+	return errors.As(err, &connErr) || errors.As(err, &netErr) ||
+		pgconn.Timeout(err) || pgconn.SafeToRetry(err) ||
+		errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// isDataError сообщает, что база отвергла сами данные: 22 — некорректное
+// значение, 23 — нарушено ограничение целостности.
+func isDataError(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (strings.HasPrefix(pgErr.Code, "22") || strings.HasPrefix(pgErr.Code, "23"))
+}
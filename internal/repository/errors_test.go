@@ -0,0 +1,48 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+
+	"order-app/internal/repository"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsTransient(t *testing.T) {
+	wrap := func(err error) error {
+		return fmt.Errorf("не удалось вставить заказ в БД: %w", err)
+	}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"connection reset", wrap(&net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}), true},
+		{"unexpected eof", wrap(io.ErrUnexpectedEOF), true},
+		{"deadline", wrap(context.DeadlineExceeded), true},
+		{"admin shutdown", wrap(&pgconn.PgError{Code: "57P01"}), true},
+		{"statement timeout", wrap(&pgconn.PgError{Code: "57014"}), true},
+		{"too many connections", wrap(&pgconn.PgError{Code: "53300"}), true},
+		{"serialization failure", wrap(&pgconn.PgError{Code: "40001"}), true},
+		{"connection failure", wrap(&pgconn.PgError{Code: "08006"}), true},
+		{"nul in jsonb", wrap(&pgconn.PgError{Code: "22P05"}), false},
+		{"not null violation", wrap(&pgconn.PgError{Code: "23502"}), false},
+		{"undefined table", wrap(&pgconn.PgError{Code: "42P01"}), false},
+		{"invalid order", fmt.Errorf("%w: %w", repository.ErrInvalidOrder, &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}), false},
+		{"plain error", errors.New("не удалось подготовить заказ к сохранению"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := repository.IsTransient(tt.err); got != tt.want {
+				t.Errorf("IsTransient(%v) = %v, ожидалось %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"order-app/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// exportFetchSize — сколько строк за раз забирается из курсора при выгрузке.
+const exportFetchSize = 500
+
+// ExportFilter отбирает заказы для выгрузки; нулевые поля не ограничивают выборку.
+// From и To сравниваются с date_created заказа, интервал полуоткрытый: [From, To).
+type ExportFilter struct {
+	DeliveryService string
+	CustomerID      string
+	From            time.Time
+	To              time.Time
+}
+
+func (f ExportFilter) Match(o *domain.Order) bool {
+	if f.DeliveryService != "" && f.DeliveryService != o.DeliveryService {
+		return false
+	}
+	if f.CustomerID != "" && f.CustomerID != o.CustomerID {
+		return false
+	}
+	if !f.From.IsZero() && o.DateCreated.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !o.DateCreated.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+// where строит условие выборки; непустой afterUID отбрасывает заказы до него
+// включительно, чтобы продолжить прерванную выгрузку.
+func (f ExportFilter) where(afterUID string) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, strings.ReplaceAll(cond, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if afterUID != "" {
+		add("order_uid > ?", afterUID)
+	}
+	if f.DeliveryService != "" {
+		add("data->>'delivery_service' = ?", f.DeliveryService)
+	}
+	if f.CustomerID != "" {
+		add("data->>'customer_id' = ?", f.CustomerID)
+	}
+	if !f.From.IsZero() {
+		add("(data->>'date_created')::timestamptz >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		add("(data->>'date_created')::timestamptz < ?", f.To)
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// ExportOrders передаёт в fn заказы, подходящие под фильтр, в порядке order_uid.
+// Строки читаются серверным курсором порциями по exportFetchSize, так что
+// в памяти не держится больше одной порции. Если реплика отказывает, выгрузка
+// продолжается с primary после последнего переданного заказа, без повторов.
+func (r *OrderRepository) ExportOrders(ctx context.Context, filter ExportFilter, fn func(*domain.Order) error) error {
+	var (
+		lastUID string
+		fnErr   error
+	)
+	emit := func(o *domain.Order) error {
+		if fnErr = fn(o); fnErr != nil {
+			return fnErr
+		}
+		lastUID = o.OrderUID
+		return nil
+	}
+
+	pool := r.readPool()
+	err := r.exportOrders(ctx, pool, filter, "", emit)
+	if err == nil || pool == r.pool || fnErr != nil || ctx.Err() != nil {
+		return err
+	}
+	return r.exportOrders(ctx, r.pool, filter, lastUID, emit)
+}
+
+func (r *OrderRepository) exportOrders(ctx context.Context, pool *pgxpool.Pool, filter ExportFilter, afterUID string, fn func(*domain.Order) error) error {
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию выгрузки: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// DECLARE — служебная команда и не принимает параметры через расширенный протокол,
+	// поэтому аргументы подставляет сам pgx в режиме простого протокола.
+	where, args := filter.where(afterUID)
+	args = append([]any{pgx.QueryExecModeSimpleProtocol}, args...)
+	if _, err := tx.Exec(ctx, "DECLARE export_cursor NO SCROLL CURSOR FOR SELECT data FROM orders"+where+" ORDER BY order_uid", args...); err != nil {
+		return fmt.Errorf("не удалось открыть курсор выгрузки: %w", err)
+	}
+
+	for {
+		n, err := r.fetchExportBatch(ctx, tx, fn)
+		if err != nil {
+			return err
+		}
+		if n < exportFetchSize {
+			return nil
+		}
+	}
+}
+
+func (r *OrderRepository) fetchExportBatch(ctx context.Context, tx pgx.Tx, fn func(*domain.Order) error) (int, error) {
+	rows, err := tx.Query(ctx, "FETCH "+strconv.Itoa(exportFetchSize)+" FROM export_cursor")
+	if err != nil {
+		return 0, fmt.Errorf("не удалось прочитать порцию выгрузки: %w", err)
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		n++
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return n, fmt.Errorf("не удалось считать данные заказа: %w", err)
+		}
+
+		o, err := decodeOrder(r.keyring, data)
+		if err != nil {
+			return n, err
+		}
+		if err := fn(o); err != nil {
+			return n, err
+		}
+	}
+
+	if rows.Err() != nil {
+		return n, fmt.Errorf("ошибка итерации по строкам результата: %w", rows.Err())
+	}
+	return n, nil
+}
+
+// ExportOrders выгружает шарды по очереди; внутри шарда заказы идут в порядке order_uid.
+func (r *ShardedOrderRepository) ExportOrders(ctx context.Context, filter ExportFilter, fn func(*domain.Order) error) error {
+	for i, s := range r.shards {
+		if err := s.repo.ExportOrders(ctx, filter, fn); err != nil {
+			return fmt.Errorf("шард #%d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (r *MemoryOrderRepository) ExportOrders(ctx context.Context, filter ExportFilter, fn func(*domain.Order) error) error {
+	after := ""
+	for {
+		page, err := r.ListOrders(ctx, after, exportFetchSize)
+		if err != nil {
+			return err
+		}
+		for _, o := range page {
+			if !filter.Match(o) {
+				continue
+			}
+			if err := fn(o); err != nil {
+				return err
+			}
+		}
+		if len(page) < exportFetchSize {
+			return nil
+		}
+		after = page[len(page)-1].OrderUID
+	}
+}
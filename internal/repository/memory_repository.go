@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"order-app/internal/domain"
+)
+
+type MemoryOrderRepository struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+	uids []string
+}
+
+var _ OrderStore = (*MemoryOrderRepository)(nil)
+
+func NewMemoryOrderRepository() *MemoryOrderRepository {
+	return &MemoryOrderRepository{data: make(map[string][]byte)}
+}
+
+func (r *MemoryOrderRepository) SaveOrder(ctx context.Context, order *domain.Order) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, fmt.Errorf("не удалось вставить заказ: %w", err)
+	}
+
+	data, err := json.Marshal(order)
+	if err != nil {
+		return false, fmt.Errorf("не удалось распарсить заказ: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.data[order.OrderUID]; exists {
+		return false, nil
+	}
+	r.data[order.OrderUID] = data
+	r.uids = append(r.uids, order.OrderUID)
+	return true, nil
+}
+
+func (r *MemoryOrderRepository) GetOrder(ctx context.Context, uid string) (*domain.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("заказ не найден или произошла ошибка запроса: %w", err)
+	}
+
+	r.mu.RLock()
+	data, exists := r.data[uid]
+	r.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("заказ %s: %w", uid, ErrOrderNotFound)
+	}
+
+	var order domain.Order
+	if err := json.Unmarshal(data, &order); err != nil {
+		return nil, fmt.Errorf("не удалось распарсить заказ: %w", err)
+	}
+	return &order, nil
+}
+
+func (r *MemoryOrderRepository) GetOrders(ctx context.Context, uids []string) ([]*domain.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("не удалось получить заказы: %w", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	orders := make([]*domain.Order, 0, len(uids))
+	seen := make(map[string]bool, len(uids))
+	for _, uid := range uids {
+		data, exists := r.data[uid]
+		if !exists || seen[uid] {
+			continue
+		}
+		seen[uid] = true
+
+		var o domain.Order
+		if err := json.Unmarshal(data, &o); err != nil {
+			return nil, fmt.Errorf("не удалось распарсить заказ: %w", err)
+		}
+		orders = append(orders, &o)
+	}
+	return orders, nil
+}
+
+func (r *MemoryOrderRepository) GetAllOrders(ctx context.Context) ([]*domain.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("не удалось получить все заказы: %w", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	orders := make([]*domain.Order, 0, len(r.uids))
+	for _, uid := range r.uids {
+		var o domain.Order
+		if err := json.Unmarshal(r.data[uid], &o); err != nil {
+			return nil, fmt.Errorf("не удалось распарсить заказ: %w", err)
+		}
+		orders = append(orders, &o)
+	}
+	return orders, nil
+}
+
+func (r *MemoryOrderRepository) ListOrders(ctx context.Context, afterUID string, limit int) ([]*domain.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("не удалось получить список заказов: %w", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	uids := make([]string, 0, len(r.uids))
+	for _, uid := range r.uids {
+		if uid > afterUID {
+			uids = append(uids, uid)
+		}
+	}
+	sort.Strings(uids)
+	if len(uids) > limit {
+		uids = uids[:limit]
+	}
+
+	orders := make([]*domain.Order, 0, len(uids))
+	for _, uid := range uids {
+		var o domain.Order
+		if err := json.Unmarshal(r.data[uid], &o); err != nil {
+			return nil, fmt.Errorf("не удалось распарсить заказ: %w", err)
+		}
+		orders = append(orders, &o)
+	}
+	return orders, nil
+}
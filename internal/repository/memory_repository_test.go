@@ -0,0 +1,14 @@
+package repository_test
+
+import (
+	"testing"
+
+	"order-app/internal/repository"
+	"order-app/internal/repository/storetest"
+)
+
+func TestMemoryOrderRepository(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) repository.OrderStore {
+		return repository.NewMemoryOrderRepository()
+	})
+}
@@ -2,26 +2,54 @@ package repository
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 
 	"order-app/internal/domain"
+	"order-app/internal/fieldcrypt"
+	"order-app/internal/tracing"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type OrderRepository struct {
-	pool *pgxpool.Pool
+	pool     *pgxpool.Pool
+	replicas *ReplicaSet
+	keyring  *fieldcrypt.Keyring
 }
 
+var _ OrderStore = (*OrderRepository)(nil)
+
 func NewOrderRepository(pool *pgxpool.Pool) *OrderRepository {
 	return &OrderRepository{pool: pool}
 }
 
-func (r *OrderRepository) SaveOrder(ctx context.Context, order *domain.Order) error {
-	data, err := json.Marshal(order)
+func (r *OrderRepository) WithReplicas(replicas *ReplicaSet) *OrderRepository {
+	r.replicas = replicas
+	return r
+}
+
+func (r *OrderRepository) WithEncryption(keyring *fieldcrypt.Keyring) *OrderRepository {
+	r.keyring = keyring
+	return r
+}
+
+func (r *OrderRepository) readPool() *pgxpool.Pool {
+	if r.replicas == nil {
+		return r.pool
+	}
+	return r.replicas.Reader()
+}
+
+func (r *OrderRepository) SaveOrder(ctx context.Context, order *domain.Order) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, "OrderRepository.SaveOrder", attribute.String("order.uid", order.OrderUID))
+	defer func() { tracing.End(span, err) }()
+
+	data, err := encodeOrder(r.keyring, order)
 	if err != nil {
-		return fmt.Errorf("не удалось распарсить заказ: %w", err)
+		return false, fmt.Errorf("не удалось подготовить заказ к сохранению: %w", err)
 	}
 
 	query := `
@@ -30,31 +58,125 @@ func (r *OrderRepository) SaveOrder(ctx context.Context, order *domain.Order) er
 		ON CONFLICT (order_uid) DO NOTHING;
 	`
 
-	_, err = r.pool.Exec(ctx, query, order.OrderUID, data)
+	tag, err := r.pool.Exec(ctx, query, order.OrderUID, data)
 	if err != nil {
-		return fmt.Errorf("не удалось вставить заказ в БД: %w", err)
+		if isDataError(err) {
+			return false, fmt.Errorf("не удалось вставить заказ в БД: %w: %w", ErrInvalidOrder, err)
+		}
+		return false, fmt.Errorf("не удалось вставить заказ в БД: %w", err)
 	}
-	return nil
+	inserted := tag.RowsAffected() > 0
+	span.SetAttributes(attribute.Bool("order.inserted", inserted))
+	return inserted, nil
 }
 
+// GetOrder читает заказ с реплики, а при ошибке или промахе повторяет запрос на
+// primary: только что записанный заказ мог ещё не дойти до реплики.
 func (r *OrderRepository) GetOrder(ctx context.Context, uid string) (*domain.Order, error) {
+	return r.lookupOrder(ctx, uid, true)
+}
+
+// lookupOrder читает заказ с реплики и переходит на primary при ошибке реплики, а
+// при промахе — только если missOnPrimary. Без него промах остаётся промахом: при
+// опросе всех шардов заказ есть лишь на одном, и остальные primary не нагружаются.
+func (r *OrderRepository) lookupOrder(ctx context.Context, uid string, missOnPrimary bool) (*domain.Order, error) {
+	pool := r.readPool()
+	order, err := r.getOrder(ctx, pool, uid)
+	if err == nil || pool == r.pool {
+		return order, err
+	}
+	if errors.Is(err, ErrOrderNotFound) && !missOnPrimary {
+		return nil, err
+	}
+	return r.getOrder(ctx, r.pool, uid)
+}
+
+func (r *OrderRepository) getOrder(ctx context.Context, pool *pgxpool.Pool, uid string) (*domain.Order, error) {
 	var data []byte
 	query := `SELECT data FROM orders WHERE order_uid = $1 LIMIT 1;`
-	err := r.pool.QueryRow(ctx, query, uid).Scan(&data)
+	err := pool.QueryRow(ctx, query, uid).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("заказ %s: %w", uid, ErrOrderNotFound)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("заказ не найден или произошла ошибка запроса: %w", err)
 	}
 
-	var order domain.Order
-	if err = json.Unmarshal(data, &order); err != nil {
-		return nil, fmt.Errorf("не удалось распарсить заказ: %w", err)
+	return decodeOrder(r.keyring, data)
+}
+
+// GetOrders, как и GetOrder, дочитывает с primary заказы, которых не нашлось на реплике.
+func (r *OrderRepository) GetOrders(ctx context.Context, uids []string) ([]*domain.Order, error) {
+	pool := r.readPool()
+	orders, err := r.getOrders(ctx, pool, uids)
+	if pool == r.pool {
+		return orders, err
+	}
+	if err != nil {
+		return r.getOrders(ctx, r.pool, uids)
+	}
+
+	found := make(map[string]bool, len(orders))
+	for _, o := range orders {
+		found[o.OrderUID] = true
+	}
+	var misses []string
+	for _, uid := range uids {
+		if !found[uid] {
+			found[uid] = true
+			misses = append(misses, uid)
+		}
+	}
+	if len(misses) == 0 {
+		return orders, nil
 	}
 
-	return &order, nil
+	rest, err := r.getOrders(ctx, r.pool, misses)
+	if err != nil {
+		return nil, err
+	}
+	return append(orders, rest...), nil
+}
+
+func (r *OrderRepository) getOrders(ctx context.Context, pool *pgxpool.Pool, uids []string) ([]*domain.Order, error) {
+	rows, err := pool.Query(ctx, `SELECT data FROM orders WHERE order_uid = ANY($1);`, uids)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить заказы: %w", err)
+	}
+	defer rows.Close()
+
+	orders := make([]*domain.Order, 0, len(uids))
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("не удалось считать данные заказа: %w", err)
+		}
+
+		o, err := decodeOrder(r.keyring, data)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("ошибка итерации по строкам результата: %w", rows.Err())
+	}
+
+	return orders, nil
 }
 
 func (r *OrderRepository) GetAllOrders(ctx context.Context) ([]*domain.Order, error) {
-	rows, err := r.pool.Query(ctx, "SELECT data FROM orders;")
+	pool := r.readPool()
+	orders, err := r.getAllOrders(ctx, pool)
+	if err != nil && pool != r.pool {
+		return r.getAllOrders(ctx, r.pool)
+	}
+	return orders, err
+}
+
+func (r *OrderRepository) getAllOrders(ctx context.Context, pool *pgxpool.Pool) ([]*domain.Order, error) {
+	rows, err := pool.Query(ctx, "SELECT data FROM orders;")
 	if err != nil {
 		return nil, fmt.Errorf("не удалось получить все заказы: %w", err)
 	}
@@ -67,11 +189,11 @@ func (r *OrderRepository) GetAllOrders(ctx context.Context) ([]*domain.Order, er
 			return nil, fmt.Errorf("не удалось считать данные заказа: %w", err)
 		}
 
-		var o domain.Order
-		if err := json.Unmarshal(data, &o); err != nil {
-			return nil, fmt.Errorf("не удалось распарсить заказ: %w", err)
+		o, err := decodeOrder(r.keyring, data)
+		if err != nil {
+			return nil, err
 		}
-		orders = append(orders, &o)
+		orders = append(orders, o)
 	}
 
 	if rows.Err() != nil {
@@ -80,3 +202,108 @@ func (r *OrderRepository) GetAllOrders(ctx context.Context) ([]*domain.Order, er
 
 	return orders, nil
 }
+
+func (r *OrderRepository) ListOrders(ctx context.Context, afterUID string, limit int) ([]*domain.Order, error) {
+	pool := r.readPool()
+	orders, err := r.listOrders(ctx, pool, afterUID, limit)
+	if err != nil && pool != r.pool {
+		return r.listOrders(ctx, r.pool, afterUID, limit)
+	}
+	return orders, err
+}
+
+func (r *OrderRepository) listOrders(ctx context.Context, pool *pgxpool.Pool, afterUID string, limit int) ([]*domain.Order, error) {
+	rows, err := pool.Query(ctx,
+		`SELECT data FROM orders WHERE order_uid > $1 ORDER BY order_uid LIMIT $2;`,
+		afterUID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось получить список заказов: %w", err)
+	}
+	defer rows.Close()
+
+	orders := make([]*domain.Order, 0, limit)
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("не удалось считать данные заказа: %w", err)
+		}
+
+		o, err := decodeOrder(r.keyring, data)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("ошибка итерации по строкам результата: %w", rows.Err())
+	}
+
+	return orders, nil
+}
+
+type ReencryptStats struct {
+	Scanned int
+	Updated int
+}
+
+func (r *OrderRepository) ReencryptPII(ctx context.Context, batchSize int, dryRun bool) (ReencryptStats, error) {
+	var stats ReencryptStats
+	if r.keyring == nil {
+		return stats, errors.New("ключи шифрования не настроены")
+	}
+
+	lastUID := ""
+	for {
+		rows, err := r.pool.Query(ctx,
+			`SELECT order_uid, data FROM orders WHERE order_uid > $1 ORDER BY order_uid LIMIT $2;`,
+			lastUID, batchSize)
+		if err != nil {
+			return stats, fmt.Errorf("не удалось выбрать заказы для перешифрования: %w", err)
+		}
+
+		type update struct {
+			uid  string
+			data []byte
+		}
+		var updates []update
+		n := 0
+		for rows.Next() {
+			var uid string
+			var data []byte
+			if err := rows.Scan(&uid, &data); err != nil {
+				rows.Close()
+				return stats, fmt.Errorf("не удалось считать данные заказа: %w", err)
+			}
+			n++
+			lastUID = uid
+
+			newData, err := reencode(r.keyring, data)
+			if err != nil {
+				rows.Close()
+				return stats, fmt.Errorf("заказ %s: %w", uid, err)
+			}
+			if newData != nil {
+				updates = append(updates, update{uid: uid, data: newData})
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return stats, fmt.Errorf("ошибка итерации по строкам результата: %w", err)
+		}
+		stats.Scanned += n
+
+		if !dryRun {
+			for _, u := range updates {
+				if _, err := r.pool.Exec(ctx, `UPDATE orders SET data = $2 WHERE order_uid = $1;`, u.uid, u.data); err != nil {
+					return stats, fmt.Errorf("не удалось обновить заказ %s: %w", u.uid, err)
+				}
+			}
+		}
+		stats.Updated += len(updates)
+
+		if n < batchSize {
+			return stats, nil
+		}
+	}
+}
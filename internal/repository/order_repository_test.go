@@ -0,0 +1,181 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"order-app/config"
+	"order-app/db"
+	"order-app/internal/domain"
+	"order-app/internal/repository"
+	"order-app/internal/repository/storetest"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// TEST_DATABASE_URL указывает на пустую базу, в которой тесты создают свои схемы.
+// Без неё проверки на Postgres пропускаются.
+const testDSNEnv = "TEST_DATABASE_URL"
+
+func TestOrderRepository(t *testing.T) {
+	pool := testPool(t, "storetest_single")
+	storetest.Run(t, func(t *testing.T) repository.OrderStore {
+		truncate(t, pool)
+		return repository.NewOrderRepository(pool)
+	})
+}
+
+func TestShardedOrderRepository(t *testing.T) {
+	pools := []*pgxpool.Pool{testPool(t, "storetest_shard_0"), testPool(t, "storetest_shard_1")}
+	storetest.Run(t, func(t *testing.T) repository.OrderStore {
+		repos := make([]*repository.OrderRepository, 0, len(pools))
+		for _, p := range pools {
+			truncate(t, p)
+			repos = append(repos, repository.NewOrderRepository(p))
+		}
+		return repository.NewShardedOrderRepository(repos...)
+	})
+}
+
+// testPool возвращает пул, работающий в отдельной схеме schema с применёнными
+// миграциями, чтобы шарды не мешали друг другу в одной базе.
+func testPool(t *testing.T, schema string) *pgxpool.Pool {
+	t.Helper()
+
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s не задан, тесты на Postgres пропущены", testDSNEnv)
+	}
+	ctx := context.Background()
+
+	admin, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("подключение к %s: %v", testDSNEnv, err)
+	}
+	_, err = admin.Exec(ctx, fmt.Sprintf("DROP SCHEMA IF EXISTS %[1]s CASCADE; CREATE SCHEMA %[1]s", schema))
+	admin.Close()
+	if err != nil {
+		t.Fatalf("создание схемы %s: %v", schema, err)
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("разбор %s: %v", testDSNEnv, err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+
+	m, err := db.NewMigrator(&config.Config{DatabaseURL: u.String()})
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	defer m.Close()
+	if err := m.Up(); err != nil {
+		t.Fatalf("миграции: %v", err)
+	}
+
+	pool, err := pgxpool.New(ctx, u.String())
+	if err != nil {
+		t.Fatalf("подключение к схеме %s: %v", schema, err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func truncate(t *testing.T, pool *pgxpool.Pool) {
+	t.Helper()
+	if _, err := pool.Exec(context.Background(), "TRUNCATE orders"); err != nil {
+		t.Fatalf("очистка orders: %v", err)
+	}
+}
+
+// Реплика — отдельная пустая схема: заказ есть только на primary, как сразу после
+// записи, пока реплика не догнала.
+func TestReplicaMissFallback(t *testing.T) {
+	primaries := []*pgxpool.Pool{testPool(t, "replica_primary_0"), testPool(t, "replica_primary_1")}
+	replicas := []*pgxpool.Pool{testPool(t, "replica_lagging_0"), testPool(t, "replica_lagging_1")}
+	repos := make([]*repository.OrderRepository, len(primaries))
+	for i := range primaries {
+		rs := repository.NewReplicaSet(primaries[i], replicas[i:i+1], time.Minute, zap.NewNop())
+		repos[i] = repository.NewOrderRepository(primaries[i]).WithReplicas(rs)
+	}
+	ctx := context.Background()
+
+	order := storetest.NewOrder("uid-replica-miss")
+	if _, err := repos[0].SaveOrder(ctx, order); err != nil {
+		t.Fatalf("SaveOrder: %v", err)
+	}
+
+	if _, err := repos[0].GetOrder(ctx, order.OrderUID); err != nil {
+		t.Fatalf("GetOrder должен дочитать заказ с primary: %v", err)
+	}
+	if _, err := repository.NewShardedOrderRepository(repos[0]).GetOrder(ctx, order.OrderUID); err != nil {
+		t.Fatalf("единственный шард должен дочитать заказ с primary: %v", err)
+	}
+
+	_, err := repository.NewShardedOrderRepository(repos...).GetOrder(ctx, order.OrderUID)
+	if !errors.Is(err, repository.ErrOrderNotFound) {
+		t.Fatalf("опрос всех шардов не должен переходить на primary при промахе, получено %v", err)
+	}
+}
+
+// Реплика без таблицы orders отказывает на любом запросе: выгрузка должна
+// целиком пройти по primary.
+func TestExportReplicaFallback(t *testing.T) {
+	primary := testPool(t, "export_primary")
+	replica := testPool(t, "export_broken_replica")
+	ctx := context.Background()
+	if _, err := replica.Exec(ctx, "DROP TABLE orders"); err != nil {
+		t.Fatalf("удаление orders на реплике: %v", err)
+	}
+	rs := repository.NewReplicaSet(primary, []*pgxpool.Pool{replica}, time.Minute, zap.NewNop())
+	repo := repository.NewOrderRepository(primary).WithReplicas(rs)
+
+	want := []string{"uid-export-1", "uid-export-2", "uid-export-3"}
+	for _, uid := range want {
+		if _, err := repo.SaveOrder(ctx, storetest.NewOrder(uid)); err != nil {
+			t.Fatalf("SaveOrder: %v", err)
+		}
+	}
+
+	var got []string
+	err := repo.ExportOrders(ctx, repository.ExportFilter{}, func(o *domain.Order) error {
+		got = append(got, o.OrderUID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportOrders должен перейти на primary: %v", err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("выгружены %v, ожидались %v", got, want)
+	}
+
+	// Ошибку самого fn повторять на primary нельзя: заказы ушли бы клиенту дважды.
+	stop := errors.New("клиент отключился")
+	calls := 0
+	err = repo.ExportOrders(ctx, repository.ExportFilter{}, func(*domain.Order) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Fatalf("ошибка fn: %v после %d вызовов", err, calls)
+	}
+}
+
+func TestSaveOrderRejectsInvalidData(t *testing.T) {
+	repo := repository.NewOrderRepository(testPool(t, "invalid_data"))
+
+	order := storetest.NewOrder("uid-nul")
+	order.Delivery.Name = "Test\x00Testov"
+	_, err := repo.SaveOrder(context.Background(), order)
+	if !errors.Is(err, repository.ErrInvalidOrder) || repository.IsTransient(err) {
+		t.Fatalf("SaveOrder с \\u0000 в jsonb: %v, ожидалась постоянная ErrInvalidOrder", err)
+	}
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+const replicaLagQuery = `
+	SELECT CASE
+		WHEN NOT pg_is_in_recovery() THEN 0
+		WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+	END;
+`
+
+type ReplicaSet struct {
+	primary  *pgxpool.Pool
+	replicas []*replica
+	maxLag   time.Duration
+	next     atomic.Uint64
+	logger   *zap.Logger
+}
+
+type replica struct {
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+	lag     atomic.Int64
+}
+
+func NewReplicaSet(primary *pgxpool.Pool, pools []*pgxpool.Pool, maxLag time.Duration, logger *zap.Logger) *ReplicaSet {
+	rs := &ReplicaSet{
+		primary: primary,
+		maxLag:  maxLag,
+		logger:  logger,
+	}
+	for _, p := range pools {
+		rep := &replica{pool: p}
+		rep.healthy.Store(true)
+		rs.replicas = append(rs.replicas, rep)
+	}
+	return rs
+}
+
+func (rs *ReplicaSet) Reader() *pgxpool.Pool {
+	n := len(rs.replicas)
+	if n == 0 {
+		return rs.primary
+	}
+
+	start := int(rs.next.Add(1) % uint64(n))
+	for i := 0; i < n; i++ {
+		rep := rs.replicas[(start+i)%n]
+		if rep.healthy.Load() {
+			return rep.pool
+		}
+	}
+	return rs.primary
+}
+
+func (rs *ReplicaSet) Start(ctx context.Context, period time.Duration) {
+	rs.checkAll(ctx)
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rs.checkAll(ctx)
+		}
+	}
+}
+
+func (rs *ReplicaSet) Close() {
+	for _, rep := range rs.replicas {
+		rep.pool.Close()
+	}
+}
+
+func (rs *ReplicaSet) checkAll(ctx context.Context) {
+	for i, rep := range rs.replicas {
+		lag, err := rs.check(ctx, rep)
+		healthy := err == nil && lag <= rs.maxLag
+		rep.lag.Store(int64(lag))
+
+		if prev := rep.healthy.Swap(healthy); prev != healthy {
+			if healthy {
+				rs.logger.Info("Реплика снова доступна для чтения", zap.Int("replica", i), zap.Duration("lag", lag))
+			} else {
+				rs.logger.Warn("Реплика исключена из чтения", zap.Int("replica", i), zap.Duration("lag", lag), zap.Error(err))
+			}
+		}
+	}
+}
+
+func (rs *ReplicaSet) check(ctx context.Context, rep *replica) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var seconds float64
+	if err := rep.pool.QueryRow(ctx, replicaLagQuery).Scan(&seconds); err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"order-app/internal/domain"
+)
+
+type ShardStats struct {
+	Shard         int    `json:"shard"`
+	Orders        int64  `json:"orders"` // оценка по статистике Postgres, не точный count(*)
+	Saves         uint64 `json:"saves"`
+	Lookups       uint64 `json:"lookups"`
+	Hits          uint64 `json:"hits"`
+	TotalConns    int32  `json:"total_conns"`
+	IdleConns     int32  `json:"idle_conns"`
+	AcquiredConns int32  `json:"acquired_conns"`
+	Error         string `json:"error,omitempty"`
+}
+
+type StatsProvider interface {
+	Stats(ctx context.Context) []ShardStats
+}
+
+type ShardedOrderRepository struct {
+	shards []*shard
+}
+
+type shard struct {
+	repo    *OrderRepository
+	saves   atomic.Uint64
+	lookups atomic.Uint64
+	hits    atomic.Uint64
+}
+
+var (
+	_ OrderStore    = (*ShardedOrderRepository)(nil)
+	_ StatsProvider = (*ShardedOrderRepository)(nil)
+)
+
+func NewShardedOrderRepository(repos ...*OrderRepository) *ShardedOrderRepository {
+	r := &ShardedOrderRepository{}
+	for _, repo := range repos {
+		r.shards = append(r.shards, &shard{repo: repo})
+	}
+	return r
+}
+
+func (r *ShardedOrderRepository) ShardFor(shardkey string) int {
+	n := len(r.shards)
+	if n == 1 {
+		return 0
+	}
+	if key, err := strconv.ParseUint(shardkey, 10, 64); err == nil {
+		return int(key % uint64(n))
+	}
+	h := fnv.New32a()
+	h.Write([]byte(shardkey))
+	return int(h.Sum32() % uint32(n))
+}
+
+func (r *ShardedOrderRepository) SaveOrder(ctx context.Context, order *domain.Order) (bool, error) {
+	s := r.shards[r.ShardFor(order.Shardkey)]
+	inserted, err := s.repo.SaveOrder(ctx, order)
+	if err != nil {
+		return false, err
+	}
+	if inserted {
+		s.saves.Add(1)
+	}
+	return inserted, nil
+}
+
+func (r *ShardedOrderRepository) GetOrder(ctx context.Context, uid string) (*domain.Order, error) {
+	if len(r.shards) == 1 {
+		return r.shards[0].get(ctx, uid, true)
+	}
+
+	// Шард по order_uid не определить, поэтому опрашиваются все. Промах на реплике
+	// не повторяется на primary, иначе каждый поиск отсутствующего заказа стоил бы
+	// по два запроса на шард.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		order *domain.Order
+		err   error
+	}
+	results := make(chan result, len(r.shards))
+	for _, s := range r.shards {
+		go func(s *shard) {
+			order, err := s.get(ctx, uid, false)
+			results <- result{order: order, err: err}
+		}(s)
+	}
+
+	var errs []error
+	for range r.shards {
+		res := <-results
+		if res.err == nil {
+			return res.order, nil
+		}
+		if !errors.Is(res.err, ErrOrderNotFound) {
+			errs = append(errs, res.err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("не удалось опросить шарды: %w", errors.Join(errs...))
+	}
+	return nil, fmt.Errorf("заказ %s: %w", uid, ErrOrderNotFound)
+}
+
+// GetOrders опрашивает все шарды параллельно: шард выбирается по shardkey,
+// которого в запросе нет, поэтому по одному order_uid шард не определить.
+func (r *ShardedOrderRepository) GetOrders(ctx context.Context, uids []string) ([]*domain.Order, error) {
+	if len(r.shards) == 1 {
+		return r.shards[0].repo.GetOrders(ctx, uids)
+	}
+
+	parts := make([][]*domain.Order, len(r.shards))
+	errs := make([]error, len(r.shards))
+	var wg sync.WaitGroup
+	for i, s := range r.shards {
+		wg.Add(1)
+		go func(i int, s *shard) {
+			defer wg.Done()
+			parts[i], errs[i] = s.repo.GetOrders(ctx, uids)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("шард #%d: %w", i, errs[i])
+			}
+		}(i, s)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	var orders []*domain.Order
+	for _, part := range parts {
+		orders = append(orders, part...)
+	}
+	return orders, nil
+}
+
+func (r *ShardedOrderRepository) GetAllOrders(ctx context.Context) ([]*domain.Order, error) {
+	var orders []*domain.Order
+	for i, s := range r.shards {
+		part, err := s.repo.GetAllOrders(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("шард #%d: %w", i, err)
+		}
+		orders = append(orders, part...)
+	}
+	return orders, nil
+}
+
+// ListOrders запрашивает по limit заказов с каждого шарда и сливает их, так что
+// страница совпадает с той, что вернула бы одна таблица.
+func (r *ShardedOrderRepository) ListOrders(ctx context.Context, afterUID string, limit int) ([]*domain.Order, error) {
+	var orders []*domain.Order
+	for i, s := range r.shards {
+		part, err := s.repo.ListOrders(ctx, afterUID, limit)
+		if err != nil {
+			return nil, fmt.Errorf("шард #%d: %w", i, err)
+		}
+		orders = append(orders, part...)
+	}
+
+	sort.Slice(orders, func(i, j int) bool { return orders[i].OrderUID < orders[j].OrderUID })
+	if len(orders) > limit {
+		orders = orders[:limit]
+	}
+	return orders, nil
+}
+
+// orderCountEstimateQuery берёт число живых строк из статистики Postgres вместо
+// count(*), который на растущей таблице превращается в полный скан на каждый запрос.
+const orderCountEstimateQuery = `
+	SELECT COALESCE((SELECT n_live_tup FROM pg_stat_user_tables WHERE relid = 'orders'::regclass), 0);
+`
+
+func (r *ShardedOrderRepository) Stats(ctx context.Context) []ShardStats {
+	stats := make([]ShardStats, len(r.shards))
+
+	var wg sync.WaitGroup
+	for i, s := range r.shards {
+		poolStat := s.repo.pool.Stat()
+		stats[i] = ShardStats{
+			Shard:         i,
+			Saves:         s.saves.Load(),
+			Lookups:       s.lookups.Load(),
+			Hits:          s.hits.Load(),
+			TotalConns:    poolStat.TotalConns(),
+			IdleConns:     poolStat.IdleConns(),
+			AcquiredConns: poolStat.AcquiredConns(),
+		}
+
+		wg.Add(1)
+		go func(st *ShardStats, s *shard) {
+			defer wg.Done()
+			if err := s.repo.pool.QueryRow(ctx, orderCountEstimateQuery).Scan(&st.Orders); err != nil {
+				st.Error = err.Error()
+			}
+		}(&stats[i], s)
+	}
+	wg.Wait()
+
+	return stats
+}
+
+func (s *shard) get(ctx context.Context, uid string, missOnPrimary bool) (*domain.Order, error) {
+	s.lookups.Add(1)
+	order, err := s.repo.lookupOrder(ctx, uid, missOnPrimary)
+	if err == nil {
+		s.hits.Add(1)
+	}
+	return order, err
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"order-app/internal/domain"
+)
+
+var ErrOrderNotFound = errors.New("заказ не найден")
+
+// ErrInvalidOrder — хранилище отклонило заказ из-за его данных (SQLSTATE классов
+// 22 и 23), например из-за \u0000 в строке, которую не принимает jsonb. Повторная
+// запись того же заказа закончится так же.
+var ErrInvalidOrder = errors.New("хранилище отклонило данные заказа")
+
+type OrderStore interface {
+	// SaveOrder сохраняет заказ, если заказа с таким order_uid ещё нет, и сообщает,
+	// была ли запись добавлена; повторное сохранение не считается ошибкой.
+	SaveOrder(ctx context.Context, order *domain.Order) (bool, error)
+	GetOrder(ctx context.Context, uid string) (*domain.Order, error)
+	// GetOrders возвращает найденные заказы из uids в произвольном порядке;
+	// отсутствующие uid не считаются ошибкой.
+	GetOrders(ctx context.Context, uids []string) ([]*domain.Order, error)
+	GetAllOrders(ctx context.Context) ([]*domain.Order, error)
+	// ListOrders возвращает до limit заказов с order_uid строго больше afterUID
+	// в порядке возрастания order_uid.
+	ListOrders(ctx context.Context, afterUID string, limit int) ([]*domain.Order, error)
+	// ExportOrders передаёт в fn заказы, подходящие под фильтр, не загружая их
+	// все в память. Ошибка fn прерывает выгрузку и возвращается как есть.
+	ExportOrders(ctx context.Context, filter ExportFilter, fn func(*domain.Order) error) error
+}
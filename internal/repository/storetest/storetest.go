@@ -0,0 +1,321 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"order-app/internal/domain"
+	"order-app/internal/repository"
+)
+
+// Run прогоняет общий набор проверок, которому должна соответствовать любая реализация
+// repository.OrderStore. newStore обязан возвращать пустое хранилище на каждый вызов.
+func Run(t *testing.T, newStore func(t *testing.T) repository.OrderStore) {
+	t.Run("SaveAndGet", func(t *testing.T) {
+		store := newStore(t)
+		ctx := context.Background()
+		order := NewOrder("uid-save-get")
+
+		if _, err := store.SaveOrder(ctx, order); err != nil {
+			t.Fatalf("SaveOrder: %v", err)
+		}
+
+		got, err := store.GetOrder(ctx, order.OrderUID)
+		if err != nil {
+			t.Fatalf("GetOrder: %v", err)
+		}
+		if got.OrderUID != order.OrderUID || got.Delivery.Email != order.Delivery.Email ||
+			len(got.Items) != len(order.Items) || !got.DateCreated.Equal(order.DateCreated) {
+			t.Fatalf("GetOrder вернул %+v, ожидался %+v", got, order)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		store := newStore(t)
+
+		_, err := store.GetOrder(context.Background(), "missing")
+		if !errors.Is(err, repository.ErrOrderNotFound) {
+			t.Fatalf("ожидалась ошибка ErrOrderNotFound, получено: %v", err)
+		}
+	})
+
+	t.Run("ConflictKeepsFirst", func(t *testing.T) {
+		store := newStore(t)
+		ctx := context.Background()
+
+		first := NewOrder("uid-conflict")
+		second := NewOrder("uid-conflict")
+		second.Delivery.Name = "Другое имя"
+
+		inserted, err := store.SaveOrder(ctx, first)
+		if err != nil {
+			t.Fatalf("SaveOrder: %v", err)
+		}
+		if !inserted {
+			t.Fatal("первый SaveOrder должен сообщить о вставке")
+		}
+		inserted, err = store.SaveOrder(ctx, second)
+		if err != nil {
+			t.Fatalf("повторный SaveOrder должен быть идемпотентным: %v", err)
+		}
+		if inserted {
+			t.Fatal("повторный SaveOrder не должен сообщать о вставке")
+		}
+
+		got, err := store.GetOrder(ctx, first.OrderUID)
+		if err != nil {
+			t.Fatalf("GetOrder: %v", err)
+		}
+		if got.Delivery.Name != first.Delivery.Name {
+			t.Fatalf("повторная вставка перезаписала заказ: %q", got.Delivery.Name)
+		}
+
+		all, err := store.GetAllOrders(ctx)
+		if err != nil {
+			t.Fatalf("GetAllOrders: %v", err)
+		}
+		if len(all) != 1 {
+			t.Fatalf("ожидался 1 заказ, получено %d", len(all))
+		}
+	})
+
+	t.Run("ReturnedOrderIsCopy", func(t *testing.T) {
+		store := newStore(t)
+		ctx := context.Background()
+		order := NewOrder("uid-copy")
+
+		if _, err := store.SaveOrder(ctx, order); err != nil {
+			t.Fatalf("SaveOrder: %v", err)
+		}
+		order.Delivery.City = "изменено после сохранения"
+
+		got, err := store.GetOrder(ctx, order.OrderUID)
+		if err != nil {
+			t.Fatalf("GetOrder: %v", err)
+		}
+		if got.Delivery.City == order.Delivery.City {
+			t.Fatal("хранилище вернуло ссылку на исходный объект вместо копии")
+		}
+	})
+
+	t.Run("GetAllOrders", func(t *testing.T) {
+		store := newStore(t)
+		ctx := context.Background()
+
+		all, err := store.GetAllOrders(ctx)
+		if err != nil {
+			t.Fatalf("GetAllOrders на пустом хранилище: %v", err)
+		}
+		if len(all) != 0 {
+			t.Fatalf("ожидалось пустое хранилище, получено %d заказов", len(all))
+		}
+
+		want := map[string]bool{}
+		for i := 0; i < 5; i++ {
+			o := NewOrder(fmt.Sprintf("uid-all-%d", i))
+			want[o.OrderUID] = true
+			if _, err := store.SaveOrder(ctx, o); err != nil {
+				t.Fatalf("SaveOrder: %v", err)
+			}
+		}
+
+		all, err = store.GetAllOrders(ctx)
+		if err != nil {
+			t.Fatalf("GetAllOrders: %v", err)
+		}
+		if len(all) != len(want) {
+			t.Fatalf("ожидалось %d заказов, получено %d", len(want), len(all))
+		}
+		for _, o := range all {
+			if !want[o.OrderUID] {
+				t.Fatalf("неожиданный заказ %s", o.OrderUID)
+			}
+		}
+	})
+
+	t.Run("GetOrders", func(t *testing.T) {
+		store := newStore(t)
+		ctx := context.Background()
+
+		for _, uid := range []string{"uid-batch-1", "uid-batch-2", "uid-batch-3"} {
+			if _, err := store.SaveOrder(ctx, NewOrder(uid)); err != nil {
+				t.Fatalf("SaveOrder: %v", err)
+			}
+		}
+
+		got, err := store.GetOrders(ctx, []string{"uid-batch-3", "missing", "uid-batch-1"})
+		if err != nil {
+			t.Fatalf("GetOrders: %v", err)
+		}
+		found := map[string]bool{}
+		for _, o := range got {
+			found[o.OrderUID] = true
+		}
+		if len(got) != 2 || !found["uid-batch-1"] || !found["uid-batch-3"] {
+			t.Fatalf("GetOrders вернул %d заказов (%v), ожидались uid-batch-1 и uid-batch-3", len(got), found)
+		}
+
+		got, err = store.GetOrders(ctx, nil)
+		if err != nil {
+			t.Fatalf("GetOrders без uid: %v", err)
+		}
+		if len(got) != 0 {
+			t.Fatalf("ожидался пустой результат, получено %d", len(got))
+		}
+	})
+
+	t.Run("ExportOrders", func(t *testing.T) {
+		store := newStore(t)
+		ctx := context.Background()
+
+		for i := 0; i < 4; i++ {
+			o := NewOrder(fmt.Sprintf("uid-export-%d", i))
+			if i%2 == 0 {
+				o.DeliveryService = "export-ds"
+			}
+			if _, err := store.SaveOrder(ctx, o); err != nil {
+				t.Fatalf("SaveOrder: %v", err)
+			}
+		}
+
+		var got []string
+		err := store.ExportOrders(ctx, repository.ExportFilter{DeliveryService: "export-ds"}, func(o *domain.Order) error {
+			got = append(got, o.OrderUID)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ExportOrders: %v", err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint([]string{"uid-export-0", "uid-export-2"}) {
+			t.Fatalf("ExportOrders вернул %v", got)
+		}
+
+		stop := errors.New("stop")
+		calls := 0
+		err = store.ExportOrders(ctx, repository.ExportFilter{}, func(*domain.Order) error {
+			calls++
+			return stop
+		})
+		if !errors.Is(err, stop) || calls != 1 {
+			t.Fatalf("ошибка fn должна прерывать выгрузку: err=%v, вызовов %d", err, calls)
+		}
+	})
+
+	t.Run("ListOrders", func(t *testing.T) {
+		store := newStore(t)
+		ctx := context.Background()
+
+		for _, i := range []int{3, 0, 4, 1, 2} {
+			if _, err := store.SaveOrder(ctx, NewOrder(fmt.Sprintf("uid-list-%d", i))); err != nil {
+				t.Fatalf("SaveOrder: %v", err)
+			}
+		}
+
+		var got []string
+		after := ""
+		for {
+			page, err := store.ListOrders(ctx, after, 2)
+			if err != nil {
+				t.Fatalf("ListOrders: %v", err)
+			}
+			if len(page) > 2 {
+				t.Fatalf("страница больше limit: %d", len(page))
+			}
+			if len(page) == 0 {
+				break
+			}
+			for _, o := range page {
+				got = append(got, o.OrderUID)
+			}
+			after = page[len(page)-1].OrderUID
+		}
+
+		want := []string{"uid-list-0", "uid-list-1", "uid-list-2", "uid-list-3", "uid-list-4"}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("ListOrders вернул %v, ожидалось %v", got, want)
+		}
+	})
+
+	t.Run("ConcurrentSave", func(t *testing.T) {
+		store := newStore(t)
+		ctx := context.Background()
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 40)
+		for i := 0; i < 40; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, err := store.SaveOrder(ctx, NewOrder(fmt.Sprintf("uid-concurrent-%d", i%10)))
+				errs <- err
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			if err != nil {
+				t.Fatalf("SaveOrder: %v", err)
+			}
+		}
+
+		all, err := store.GetAllOrders(ctx)
+		if err != nil {
+			t.Fatalf("GetAllOrders: %v", err)
+		}
+		if len(all) != 10 {
+			t.Fatalf("ожидалось 10 уникальных заказов, получено %d", len(all))
+		}
+	})
+}
+
+func NewOrder(uid string) *domain.Order {
+	return &domain.Order{
+		OrderUID:    uid,
+		TrackNumber: 9934930,
+		Entry:       "WBIL",
+		Delivery: domain.DeliveryInfo{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+		Payment: domain.PaymentInfo{
+			Transaction:  uid,
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       1817,
+			PaymentDt:    1637907727,
+			Bank:         "alpha",
+			DeliveryCost: 1500,
+			GoodsTotal:   317,
+		},
+		Items: []domain.Item{{
+			ChrtID:      9934930,
+			TrackNumber: "WBILMTESTTRACK",
+			Price:       453,
+			Rid:         "ab4219087a764ae0btest",
+			Name:        "Mascaras",
+			Sale:        30,
+			Size:        "0",
+			TotalPrice:  317,
+			NmID:        2389212,
+			Brand:       "Vivienne Sabo",
+			Status:      202,
+		}},
+		Locale:          "en",
+		CustomerID:      "test",
+		DeliveryService: "meest",
+		Shardkey:        "9",
+		SmID:            99,
+		DateCreated:     time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+		OofShard:        "1",
+	}
+}
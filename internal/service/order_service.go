@@ -2,25 +2,62 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync/atomic"
 
 	"order-app/internal/cache"
 	"order-app/internal/domain"
+	"order-app/internal/logger"
+	"order-app/internal/pubsub"
 	"order-app/internal/repository"
+	"order-app/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
 )
 
+var ErrStatsUnsupported = errors.New("хранилище не предоставляет статистику")
+
+// maxDroppedOrders — сколько заказов подряд может пропустить подписчик, прежде
+// чем его подписка будет закрыта как отставшая.
+const maxDroppedOrders = 256
+
 type OrderService struct {
-	repo  *repository.OrderRepository
-	cache *cache.OrderCache
+	repo      repository.OrderStore
+	cache     *cache.OrderCache
+	cacheWarm atomic.Bool
+	logger    *zap.Logger
+	feed      *pubsub.Broker[*domain.Order]
 }
 
-func NewOrderService(repo *repository.OrderRepository, cache *cache.OrderCache) *OrderService {
+// OrderFilter отбирает заказы для подписки; пустые поля не ограничивают выборку.
+type OrderFilter struct {
+	DeliveryService string
+	CustomerID      string
+}
+
+func (f OrderFilter) Match(o *domain.Order) bool {
+	if f.DeliveryService != "" && f.DeliveryService != o.DeliveryService {
+		return false
+	}
+	if f.CustomerID != "" && f.CustomerID != o.CustomerID {
+		return false
+	}
+	return true
+}
+
+func NewOrderService(repo repository.OrderStore, cache *cache.OrderCache, logger *zap.Logger) *OrderService {
 	return &OrderService{
-		repo:  repo,
-		cache: cache,
+		repo:   repo,
+		cache:  cache,
+		logger: logger,
+		feed:   pubsub.NewBroker[*domain.Order](maxDroppedOrders),
 	}
 }
 
+// RestoreCache загружает заказы из хранилища в кэш. Кэш считается прогретым
+// только после успешной загрузки; при ошибке вызов можно повторить.
 func (s *OrderService) RestoreCache() (int, error) {
 	ctx := context.Background()
 	orders, err := s.repo.GetAllOrders(ctx)
@@ -32,32 +69,153 @@ func (s *OrderService) RestoreCache() (int, error) {
 		s.cache.Set(o)
 	}
 
+	s.cacheWarm.Store(true)
 	return len(orders), nil
 }
 
-func (s *OrderService) GetOrder(ctx context.Context, orderUID string) (*domain.Order, error) {
+func (s *OrderService) CacheWarm() bool {
+	return s.cacheWarm.Load()
+}
+
+func (s *OrderService) GetOrder(ctx context.Context, orderUID string) (_ *domain.Order, err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.GetOrder", attribute.String("order.uid", orderUID))
+	defer func() { tracing.End(span, err) }()
+
 	order, found := s.cache.Get(orderUID)
+	span.SetAttributes(attribute.Bool("cache.hit", found))
 	if found {
 		return order, nil
 	}
+	logger.FromContext(ctx, s.logger).Debug("Заказа нет в кэше, запрашиваем из БД", zap.String("order_uid", orderUID))
 
-	order, err := s.repo.GetOrder(ctx, orderUID)
+	order, err = s.repo.GetOrder(ctx, orderUID)
 	if err != nil {
 		return nil, err
 	}
 
-	s.cache.Set(order)
+	s.setCache(ctx, order)
 	return order, nil
 }
 
-func (s *OrderService) ProcessOrder(ctx context.Context, order *domain.Order) error {
+// GetOrders отдаёт заказы из кэша, а недостающие запрашивает из хранилища одним
+// запросом. Найденные заказы возвращаются в порядке uids без повторов, missing —
+// uid, которых нет ни в кэше, ни в хранилище.
+func (s *OrderService) GetOrders(ctx context.Context, uids []string) (found []*domain.Order, missing []string, err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.GetOrders", attribute.Int("orders.requested", len(uids)))
+	defer func() { tracing.End(span, err) }()
+
+	byUID := make(map[string]*domain.Order, len(uids))
+	var misses []string
+	for _, uid := range uids {
+		if _, seen := byUID[uid]; seen {
+			continue
+		}
+		order, ok := s.cache.Get(uid)
+		byUID[uid] = order
+		if !ok {
+			misses = append(misses, uid)
+		}
+	}
+	span.SetAttributes(attribute.Int("cache.misses", len(misses)))
+
+	if len(misses) > 0 {
+		logger.FromContext(ctx, s.logger).Debug("Часть заказов не найдена в кэше, запрашиваем из БД", zap.Int("count", len(misses)))
+		orders, err := s.repo.GetOrders(ctx, misses)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, o := range orders {
+			byUID[o.OrderUID] = o
+			s.cache.Set(o)
+		}
+	}
+
+	found = make([]*domain.Order, 0, len(byUID))
+	for _, uid := range uids {
+		order, ok := byUID[uid]
+		if !ok {
+			continue
+		}
+		delete(byUID, uid)
+		if order == nil {
+			missing = append(missing, uid)
+			continue
+		}
+		found = append(found, order)
+	}
+	return found, missing, nil
+}
+
+// ProcessOrder сохраняет заказ и, если он новый, кладёт его в кэш и публикует
+// подписчикам. Как и OrderStore.SaveOrder, возвращает false без ошибки, если заказ
+// уже был сохранён.
+func (s *OrderService) ProcessOrder(ctx context.Context, order *domain.Order) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.ProcessOrder")
+	defer func() { tracing.End(span, err) }()
+
 	if order == nil {
-		return fmt.Errorf("заказ пуст")
+		return false, fmt.Errorf("заказ пуст")
 	}
+	span.SetAttributes(attribute.String("order.uid", order.OrderUID))
 
-	if err := s.repo.SaveOrder(ctx, order); err != nil {
-		return err
+	inserted, err := s.repo.SaveOrder(ctx, order)
+	if err != nil {
+		return false, err
+	}
+	if !inserted {
+		// Заказ уже сохранён (повторная доставка или перечитывание топика): в кэше и
+		// у подписчиков он уже есть, а в хранилище осталась первая версия.
+		logger.FromContext(ctx, s.logger).Debug("Заказ уже сохранён, повторная запись пропущена", zap.String("order_uid", order.OrderUID))
+		return false, nil
 	}
+	s.setCache(ctx, order)
+	s.feed.Publish(order)
+	return true, nil
+}
+
+// ListOrders возвращает страницу заказов после afterUID в порядке order_uid.
+func (s *OrderService) ListOrders(ctx context.Context, afterUID string, limit int) (_ []*domain.Order, err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.ListOrders", attribute.String("after_uid", afterUID), attribute.Int("limit", limit))
+	defer func() { tracing.End(span, err) }()
+
+	return s.repo.ListOrders(ctx, afterUID, limit)
+}
+
+// ExportOrders передаёт в fn заказы из хранилища, минуя кэш.
+func (s *OrderService) ExportOrders(ctx context.Context, filter repository.ExportFilter, fn func(*domain.Order) error) (err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.ExportOrders")
+	defer func() { tracing.End(span, err) }()
+
+	return s.repo.ExportOrders(ctx, filter, fn)
+}
+
+// Subscribe подписывает на заказы, сохранённые после момента подписки. Медленный
+// подписчик пропускает заказы, а при длительном отставании его подписка закрывается
+// (см. Subscription.Lagged). Подписку нужно закрыть вызовом Close.
+func (s *OrderService) Subscribe(filter OrderFilter, buffer int) *pubsub.Subscription[*domain.Order] {
+	return s.feed.Subscribe(buffer, filter.Match)
+}
+
+// CloseSubscriptions закрывает все подписки, чтобы долгие стримы не держали остановку сервера.
+func (s *OrderService) CloseSubscriptions() {
+	s.feed.Close()
+}
+
+func (s *OrderService) Subscribers() int {
+	return s.feed.Subscribers()
+}
+
+func (s *OrderService) setCache(ctx context.Context, order *domain.Order) {
+	_, span := tracing.Start(ctx, "OrderCache.Set", attribute.String("order.uid", order.OrderUID))
+	defer span.End()
+
 	s.cache.Set(order)
-	return nil
+}
+
+func (s *OrderService) StorageStats(ctx context.Context) ([]repository.ShardStats, error) {
+	provider, ok := s.repo.(repository.StatsProvider)
+	if !ok {
+		return nil, ErrStatsUnsupported
+	}
+	return provider.Stats(ctx), nil
 }
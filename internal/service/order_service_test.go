@@ -0,0 +1,183 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"order-app/internal/cache"
+	"order-app/internal/domain"
+	"order-app/internal/repository"
+	"order-app/internal/repository/storetest"
+	"order-app/internal/service"
+
+	"go.uber.org/zap"
+)
+
+func TestProcessOrderSkipsDuplicate(t *testing.T) {
+	orders := cache.NewCache(time.Minute)
+	svc := service.NewOrderService(repository.NewMemoryOrderRepository(), orders, zap.NewNop())
+	sub := svc.Subscribe(service.OrderFilter{}, 4)
+	defer sub.Close()
+	ctx := context.Background()
+
+	first := storetest.NewOrder("uid-duplicate")
+	if inserted, err := svc.ProcessOrder(ctx, first); err != nil || !inserted {
+		t.Fatalf("ProcessOrder: %v, %v", inserted, err)
+	}
+	select {
+	case got := <-sub.C():
+		if got.OrderUID != first.OrderUID {
+			t.Fatalf("опубликован %q, ожидался %q", got.OrderUID, first.OrderUID)
+		}
+	default:
+		t.Fatal("новый заказ не опубликован")
+	}
+
+	second := storetest.NewOrder("uid-duplicate")
+	second.Delivery.Name = "Другое имя"
+	if inserted, err := svc.ProcessOrder(ctx, second); err != nil || inserted {
+		t.Fatalf("повторный ProcessOrder: %v, %v", inserted, err)
+	}
+	select {
+	case got := <-sub.C():
+		t.Fatalf("повторный заказ опубликован: %q", got.OrderUID)
+	default:
+	}
+
+	cached, ok := orders.Get(first.OrderUID)
+	if !ok {
+		t.Fatal("заказ пропал из кэша")
+	}
+	if cached.Delivery.Name != first.Delivery.Name {
+		t.Fatalf("повторный заказ перезаписал кэш: %q", cached.Delivery.Name)
+	}
+}
+
+// recordingStore запоминает, какие uid сервис запросил у хранилища пачкой.
+type recordingStore struct {
+	*repository.MemoryOrderRepository
+	batches [][]string
+	err     error
+}
+
+func (s *recordingStore) GetOrders(ctx context.Context, uids []string) ([]*domain.Order, error) {
+	s.batches = append(s.batches, append([]string(nil), uids...))
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.MemoryOrderRepository.GetOrders(ctx, uids)
+}
+
+func TestGetOrders(t *testing.T) {
+	tests := []struct {
+		name        string
+		cached      []string
+		stored      []string
+		uids        []string
+		wantFound   []string
+		wantMissing []string
+		wantBatches [][]string
+	}{
+		{
+			name:        "all cached",
+			cached:      []string{"a", "b"},
+			uids:        []string{"b", "a"},
+			wantFound:   []string{"b", "a"},
+			wantBatches: nil,
+		},
+		{
+			name:        "partially warm cache",
+			cached:      []string{"a"},
+			stored:      []string{"b", "c"},
+			uids:        []string{"c", "a", "b"},
+			wantFound:   []string{"c", "a", "b"},
+			wantBatches: [][]string{{"c", "b"}},
+		},
+		{
+			name:        "duplicates",
+			cached:      []string{"a"},
+			stored:      []string{"b"},
+			uids:        []string{"b", "a", "b", "a", "b"},
+			wantFound:   []string{"b", "a"},
+			wantBatches: [][]string{{"b"}},
+		},
+		{
+			name:        "missing",
+			cached:      []string{"a"},
+			stored:      []string{"b"},
+			uids:        []string{"x", "a", "y", "b", "x"},
+			wantFound:   []string{"a", "b"},
+			wantMissing: []string{"x", "y"},
+			wantBatches: [][]string{{"x", "y", "b"}},
+		},
+		{
+			name:        "nothing found",
+			uids:        []string{"x"},
+			wantFound:   []string{},
+			wantMissing: []string{"x"},
+			wantBatches: [][]string{{"x"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := &recordingStore{MemoryOrderRepository: repository.NewMemoryOrderRepository()}
+			orders := cache.NewCache(time.Minute)
+			for _, uid := range tt.stored {
+				if _, err := store.SaveOrder(ctx, storetest.NewOrder(uid)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, uid := range tt.cached {
+				orders.Set(storetest.NewOrder(uid))
+			}
+			svc := service.NewOrderService(store, orders, zap.NewNop())
+
+			found, missing, err := svc.GetOrders(ctx, tt.uids)
+			if err != nil {
+				t.Fatalf("GetOrders: %v", err)
+			}
+			if got := orderUIDs(found); !slices.Equal(got, tt.wantFound) {
+				t.Errorf("найдены %v, ожидались %v", got, tt.wantFound)
+			}
+			if !slices.Equal(missing, tt.wantMissing) {
+				t.Errorf("не найдены %v, ожидались %v", missing, tt.wantMissing)
+			}
+			if !slices.EqualFunc(store.batches, tt.wantBatches, slices.Equal) {
+				t.Errorf("запросы к хранилищу %v, ожидались %v", store.batches, tt.wantBatches)
+			}
+
+			// Дочитанные из хранилища заказы оседают в кэше.
+			store.batches = nil
+			if _, _, err := svc.GetOrders(ctx, tt.wantFound); err != nil {
+				t.Fatalf("повторный GetOrders: %v", err)
+			}
+			if len(store.batches) != 0 {
+				t.Errorf("повторный запрос найденных заказов ушёл в хранилище: %v", store.batches)
+			}
+		})
+	}
+}
+
+func TestGetOrdersStoreError(t *testing.T) {
+	storeErr := errors.New("хранилище недоступно")
+	store := &recordingStore{MemoryOrderRepository: repository.NewMemoryOrderRepository(), err: storeErr}
+	svc := service.NewOrderService(store, cache.NewCache(time.Minute), zap.NewNop())
+
+	found, missing, err := svc.GetOrders(context.Background(), []string{"a"})
+	if !errors.Is(err, storeErr) || found != nil || missing != nil {
+		t.Fatalf("GetOrders: %v, %v, %v, ожидалась ошибка хранилища", found, missing, err)
+	}
+}
+
+func orderUIDs(orders []*domain.Order) []string {
+	uids := make([]string, 0, len(orders))
+	for _, o := range orders {
+		uids = append(uids, o.OrderUID)
+	}
+	return uids
+}
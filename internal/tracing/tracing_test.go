@@ -0,0 +1,133 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"order-app/internal/cache"
+	"order-app/internal/domain"
+	"order-app/internal/handler"
+	"order-app/internal/health"
+	"order-app/internal/ratelimit"
+	"order-app/internal/repository"
+	"order-app/internal/repository/storetest"
+	"order-app/internal/service"
+	"order-app/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+func newRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	rec := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return rec
+}
+
+func spanByName(t *testing.T, rec *tracetest.SpanRecorder, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	for _, s := range rec.Ended() {
+		if s.Name() == name {
+			return s
+		}
+	}
+	t.Fatalf("спан %q не записан", name)
+	return nil
+}
+
+func assertChild(t *testing.T, child, parent sdktrace.ReadOnlySpan) {
+	t.Helper()
+	if child.Parent().SpanID() != parent.SpanContext().SpanID() || child.SpanContext().TraceID() != parent.SpanContext().TraceID() {
+		t.Errorf("спан %q должен быть дочерним для %q", child.Name(), parent.Name())
+	}
+}
+
+// tracedStore добавляет спаны хранилища к MemoryOrderRepository, как это делает
+// OrderRepository, чтобы проверить передачу контекста из сервиса в хранилище.
+type tracedStore struct {
+	*repository.MemoryOrderRepository
+}
+
+func (s tracedStore) GetOrder(ctx context.Context, uid string) (_ *domain.Order, err error) {
+	ctx, span := tracing.Start(ctx, "OrderRepository.GetOrder")
+	defer func() { tracing.End(span, err) }()
+	return s.MemoryOrderRepository.GetOrder(ctx, uid)
+}
+
+func TestEndRecordsError(t *testing.T) {
+	rec := newRecorder(t)
+
+	ctx, parent := tracing.Start(context.Background(), "parent")
+	_, child := tracing.Start(ctx, "child")
+	tracing.End(child, errors.New("boom"))
+	tracing.End(parent, nil)
+
+	c, p := spanByName(t, rec, "child"), spanByName(t, rec, "parent")
+	assertChild(t, c, p)
+	if c.Status().Code != codes.Error || len(c.Events()) == 0 {
+		t.Errorf("ошибка не записана в спан: статус %v, событий %d", c.Status(), len(c.Events()))
+	}
+	if p.Status().Code == codes.Error {
+		t.Errorf("спан без ошибки получил статус %v", p.Status())
+	}
+}
+
+func TestHTTPRequestSpans(t *testing.T) {
+	rec := newRecorder(t)
+
+	order := storetest.NewOrder("uid-http-trace")
+	store := tracedStore{repository.NewMemoryOrderRepository()}
+	if _, err := store.SaveOrder(context.Background(), order); err != nil {
+		t.Fatalf("SaveOrder: %v", err)
+	}
+	svc := service.NewOrderService(store, cache.NewCache(time.Minute), zap.NewNop())
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(otelgin.Middleware("order-app-test"), handler.RequestID(zap.NewNop()))
+	var limiter *ratelimit.Limiter
+	handler.RegisterRoutes(r, svc, health.NewChecker(time.Second), nil, limiter, zap.NewNop())
+
+	remote := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	req := httptest.NewRequest(http.MethodGet, "/order/"+order.OrderUID, nil)
+	otel.GetTextMapPropagator().Inject(trace.ContextWithRemoteSpanContext(context.Background(), remote), propagation.HeaderCarrier(req.Header))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /order/:id вернул %d: %s", w.Code, w.Body)
+	}
+
+	server := spanByName(t, rec, "/order/:id")
+	if server.Parent().SpanID() != remote.SpanID() || server.SpanContext().TraceID() != remote.TraceID() {
+		t.Errorf("серверный спан не продолжает трассу из traceparent: родитель %v", server.Parent())
+	}
+	svcSpan := spanByName(t, rec, "OrderService.GetOrder")
+	assertChild(t, svcSpan, server)
+	assertChild(t, spanByName(t, rec, "OrderRepository.GetOrder"), svcSpan)
+}
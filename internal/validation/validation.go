@@ -0,0 +1,125 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"order-app/internal/domain"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// OrderValidator проверяет сырые заказы по JSON-схеме и разбирает их в domain.Order.
+// Используется и consumer Kafka, и импортом из файлов, чтобы правила не расходились.
+type OrderValidator struct {
+	schema *gojsonschema.Schema
+}
+
+func NewOrderValidator() (*OrderValidator, error) {
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(orderSchemaJSON))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось загрузить JSON-схему: %w", err)
+	}
+	return &OrderValidator{schema: schema}, nil
+}
+
+func (v *OrderValidator) Parse(data []byte) (*domain.Order, error) {
+	result, err := v.schema.Validate(gojsonschema.NewBytesLoader(data))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось валидировать JSON: %w", err)
+	}
+	if !result.Valid() {
+		return nil, fmt.Errorf("JSON не соответствует схеме: %s", describeSchemaErrors(result.Errors()))
+	}
+
+	var order domain.Order
+	if err := json.Unmarshal(data, &order); err != nil {
+		return nil, fmt.Errorf("не удалось распарсить заказ: %w", err)
+	}
+
+	return &order, nil
+}
+
+func describeSchemaErrors(errs []gojsonschema.ResultError) string {
+	parts := make([]string, 0, len(errs))
+	for _, e := range errs {
+		part := e.Field() + ": " + e.Type()
+		// Имя отсутствующего поля берётся из схемы, значений из сообщения здесь нет.
+		if prop, ok := e.Details()["property"].(string); ok && e.Type() == "required" {
+			part += " " + prop
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "; ")
+}
+
+const orderSchemaJSON = `
+{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "required": ["order_uid", "track_number", "entry", "delivery", "payment", "items", "locale", "customer_id", "delivery_service", "shardkey", "sm_id", "date_created", "oof_shard"],
+  "properties": {
+    "order_uid": {"type": "string"},
+    "track_number": {"type": "integer"},
+    "entry": {"type": "string"},
+    "delivery": {
+      "type": "object",
+      "required": ["name", "phone", "zip", "city", "address", "region", "email"],
+      "properties": {
+        "name": {"type": "string"},
+        "phone": {"type": "string"},
+        "zip": {"type": "string"},
+        "city": {"type": "string"},
+        "address": {"type": "string"},
+        "region": {"type": "string"},
+        "email": {"type": "string", "format": "email"}
+      }
+    },
+    "payment": {
+      "type": "object",
+      "required": ["transaction", "currency", "provider", "amount", "payment_dt", "bank", "delivery_cost", "goods_total", "custom_fee"],
+      "properties": {
+        "transaction": {"type": "string"},
+        "request_id": {"type": "string"},
+        "currency": {"type": "string"},
+        "provider": {"type": "string"},
+        "amount": {"type": "integer"},
+        "payment_dt": {"type": "integer"},
+        "bank": {"type": "string"},
+        "delivery_cost": {"type": "integer"},
+        "goods_total": {"type": "integer"},
+        "custom_fee": {"type": "integer"}
+      }
+    },
+    "items": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["chrt_id", "track_number", "price", "rid", "name", "sale", "size", "total_price", "nm_id", "brand", "status"],
+        "properties": {
+          "chrt_id": {"type": "integer"},
+          "track_number": {"type": "string"},
+          "price": {"type": "integer"},
+          "rid": {"type": "string"},
+          "name": {"type": "string"},
+          "sale": {"type": "integer"},
+          "size": {"type": "string"},
+          "total_price": {"type": "integer"},
+          "nm_id": {"type": "integer"},
+          "brand": {"type": "string"},
+          "status": {"type": "integer"}
+        }
+      }
+    },
+    "locale": {"type": "string"},
+    "internal_signature": {"type": "string"},
+    "customer_id": {"type": "string"},
+    "delivery_service": {"type": "string"},
+    "shardkey": {"type": "string"},
+    "sm_id": {"type": "integer"},
+    "date_created": {"type": "string", "format": "date-time"},
+    "oof_shard": {"type": "string"}
+  }
+}
+`
@@ -0,0 +1,201 @@
+"use strict";
+
+const RECENT_KEY = "orders.recent";
+const API_KEY = "orders.apiKey";
+const RECENT_LIMIT = 10;
+
+const form = document.getElementById("search");
+const uidInput = document.getElementById("order-uid");
+const apiKeyInput = document.getElementById("api-key");
+const result = document.getElementById("result");
+const recentList = document.getElementById("recent");
+
+apiKeyInput.value = sessionStorage.getItem(API_KEY) || "";
+apiKeyInput.addEventListener("change", () => sessionStorage.setItem(API_KEY, apiKeyInput.value));
+
+form.addEventListener("submit", (e) => {
+    e.preventDefault();
+    const uid = uidInput.value.trim();
+    if (uid) {
+        location.hash = encodeURIComponent(uid);
+    }
+});
+
+document.getElementById("clear-recent").addEventListener("click", () => {
+    localStorage.removeItem(RECENT_KEY);
+    renderRecent();
+});
+
+window.addEventListener("hashchange", lookupFromHash);
+
+renderRecent();
+lookupFromHash();
+
+function lookupFromHash() {
+    const uid = decodeURIComponent(location.hash.slice(1));
+    if (uid) {
+        uidInput.value = uid;
+        lookup(uid);
+    }
+}
+
+async function lookup(uid) {
+    showMessage("Загрузка…", "placeholder");
+
+    const headers = {};
+    if (apiKeyInput.value) {
+        headers["X-API-Key"] = apiKeyInput.value;
+    }
+
+    let resp;
+    try {
+        resp = await fetch("/order/" + encodeURIComponent(uid), {headers});
+    } catch (err) {
+        showMessage("Сервис недоступен. Проверьте соединение и повторите попытку.", "error");
+        return;
+    }
+
+    if (resp.ok) {
+        renderOrder(await resp.json());
+        remember(uid);
+        return;
+    }
+
+    switch (resp.status) {
+        case 404:
+            showMessage("Заказ " + uid + " не найден.", "error");
+            break;
+        case 401:
+            document.getElementById("credentials").open = true;
+            showMessage("Требуется аутентификация: укажите API-ключ.", "error");
+            break;
+        case 403:
+            showMessage("У ключа нет прав на просмотр заказов.", "error");
+            break;
+        case 429:
+            showMessage("Слишком много запросов. Повторите через " + (resp.headers.get("Retry-After") || "несколько") + " с.", "error");
+            break;
+        default:
+            showMessage("Ошибка сервера (" + resp.status + "). Повторите попытку позже.", "error");
+    }
+}
+
+function renderOrder(order) {
+    const view = document.getElementById("order-template").content.cloneNode(true);
+
+    view.querySelector(".order-uid").textContent = order.order_uid;
+    fillList(view.querySelector(".summary"), [
+        ["Трек-номер", order.track_number],
+        ["Создан", formatDate(order.date_created)],
+        ["Покупатель", order.customer_id],
+        ["Служба доставки", order.delivery_service],
+        ["Локаль", order.locale],
+        ["Entry", order.entry],
+    ]);
+
+    const d = order.delivery || {};
+    fillList(view.querySelector(".delivery"), [
+        ["Получатель", d.name],
+        ["Телефон", d.phone],
+        ["Email", d.email],
+        ["Адрес", [d.zip, d.region, d.city, d.address].filter(Boolean).join(", ")],
+    ]);
+
+    const p = order.payment || {};
+    fillList(view.querySelector(".payment"), [
+        ["Транзакция", p.transaction],
+        ["Провайдер", p.provider],
+        ["Банк", p.bank],
+        ["Оплачено", formatDate(p.payment_dt ? p.payment_dt * 1000 : null)],
+        ["Товары", formatMoney(p.goods_total, p.currency)],
+        ["Доставка", formatMoney(p.delivery_cost, p.currency)],
+        ["Комиссия", formatMoney(p.custom_fee, p.currency)],
+        ["Итого", formatMoney(p.amount, p.currency)],
+    ]);
+
+    const tbody = view.querySelector(".items tbody");
+    for (const item of order.items || []) {
+        const row = tbody.insertRow();
+        for (const value of [
+            item.name,
+            item.brand,
+            item.size,
+            formatMoney(item.price, p.currency),
+            item.sale == null ? null : item.sale + "%",
+            formatMoney(item.total_price, p.currency),
+            item.status,
+        ]) {
+            row.insertCell().textContent = value ?? "—";
+        }
+    }
+
+    result.replaceChildren(view);
+}
+
+function fillList(dl, rows) {
+    for (const [label, value] of rows) {
+        const dt = document.createElement("dt");
+        dt.textContent = label;
+        const dd = document.createElement("dd");
+        dd.textContent = value === undefined || value === null || value === "" ? "—" : value;
+        dl.append(dt, dd);
+    }
+}
+
+function formatDate(value) {
+    if (!value) {
+        return null;
+    }
+    const date = new Date(value);
+    return isNaN(date) ? value : date.toLocaleString();
+}
+
+function formatMoney(amount, currency) {
+    if (amount === undefined || amount === null) {
+        return null;
+    }
+    try {
+        return new Intl.NumberFormat(undefined, {style: "currency", currency}).format(amount);
+    } catch (err) {
+        return amount + " " + (currency || "");
+    }
+}
+
+function showMessage(text, className) {
+    const p = document.createElement("p");
+    p.className = className;
+    p.textContent = text;
+    result.replaceChildren(p);
+}
+
+function loadRecent() {
+    try {
+        return JSON.parse(localStorage.getItem(RECENT_KEY)) || [];
+    } catch (err) {
+        return [];
+    }
+}
+
+function remember(uid) {
+    const recent = [uid, ...loadRecent().filter((v) => v !== uid)].slice(0, RECENT_LIMIT);
+    localStorage.setItem(RECENT_KEY, JSON.stringify(recent));
+    renderRecent();
+}
+
+function renderRecent() {
+    const recent = loadRecent();
+    recentList.replaceChildren(...recent.map((uid) => {
+        const a = document.createElement("a");
+        a.href = "#" + encodeURIComponent(uid);
+        a.textContent = uid;
+        const li = document.createElement("li");
+        li.append(a);
+        return li;
+    }));
+    if (recent.length === 0) {
+        const li = document.createElement("li");
+        li.className = "placeholder";
+        li.textContent = "Пока пусто";
+        recentList.append(li);
+    }
+}
@@ -0,0 +1,26 @@
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// Register отдаёт страницу поиска заказов по /ui/. Данные страница получает
+// из GET /order/:id, поэтому аутентификация и лимиты действуют как для API.
+func Register(r *gin.Engine) {
+	static, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		panic(err)
+	}
+
+	r.StaticFS("/ui", http.FS(static))
+	r.GET("/", func(c *gin.Context) {
+		c.Redirect(http.StatusFound, "/ui/")
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/brianvoe/gofakeit/v6"
@@ -81,7 +82,7 @@ func main() {
 	}
 
 	w := &kafka.Writer{
-		Addr:     kafka.TCP(kafkaBrokers),
+		Addr:     kafka.TCP(strings.Split(kafkaBrokers, ",")...),
 		Topic:    kafkaTopic,
 		Balancer: &kafka.LeastBytes{},
 	}